@@ -0,0 +1,127 @@
+// Package acstring implements a parser of the Google Additional Consent string ( AC string )
+//
+// The AC string lists the Google ATP providers, which are not registered in the IAB Global Vendor List,
+// user has given his consent to. It's made of the spec version followed by the consented provider IDs
+// and, since version 2, by the disclosed provider IDs. For instance "2~1.35.41.101~dv.9.21.81".
+//
+// It's implemented according to specification: https://support.google.com/admanager/answer/9681920
+package acstring
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	disclosedPrefix = "dv."
+)
+
+// AddtlConsent is a parsed AC string
+//
+// note: provider IDs are sorted and without duplicates. DisclosedProviders is nil when the AC string has no
+// disclosed providers part, and empty when the part has no IDs.
+type AddtlConsent struct {
+	Version            int
+	ConsentedProviders []int
+	DisclosedProviders []int
+}
+
+// Parse parses an AC string
+//
+// note: version 1 strings have no disclosed providers part, it's optional in version 2 strings too
+func Parse(s string) (*AddtlConsent, error) {
+	if s == "" {
+		return nil, fmt.Errorf("ac string is empty")
+	}
+	parts := strings.Split(s, "~")
+
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("version parse failed: %w", err)
+	}
+	switch {
+	case version != 1 && version != 2:
+		return nil, fmt.Errorf("unsupported version %d", version)
+	case len(parts) < 2:
+		return nil, fmt.Errorf("consented providers are missing")
+	case len(parts) > 3 || (version == 1 && len(parts) > 2):
+		return nil, fmt.Errorf("too many parts for version %d", version)
+	}
+
+	ac := &AddtlConsent{Version: version}
+	if ac.ConsentedProviders, err = parseIDs(parts[1]); err != nil {
+		return nil, fmt.Errorf("consented providers parse failed: %w", err)
+	}
+	if len(parts) == 3 {
+		disclosed, ok := strings.CutPrefix(parts[2], disclosedPrefix)
+		if !ok {
+			return nil, fmt.Errorf("disclosed providers must start with %q", disclosedPrefix)
+		}
+		if ac.DisclosedProviders, err = parseIDs(disclosed); err != nil {
+			return nil, fmt.Errorf("disclosed providers parse failed: %w", err)
+		}
+	}
+	return ac, nil
+}
+
+// ProviderAllowed checks if user has given his consent to the provider
+func (ac *AddtlConsent) ProviderAllowed(id int) bool {
+	_, found := slices.BinarySearch(ac.ConsentedProviders, id)
+	return found
+}
+
+// ProviderDisclosed checks if the provider has been disclosed to user
+//
+// note: consented providers are disclosed too, but they are not listed in the disclosed providers part
+func (ac *AddtlConsent) ProviderDisclosed(id int) bool {
+	_, found := slices.BinarySearch(ac.DisclosedProviders, id)
+	return found || ac.ProviderAllowed(id)
+}
+
+// String returns the AC string
+//
+// note: the disclosed providers part is omitted when DisclosedProviders is nil, so a parsed AC string is returned as is
+func (ac *AddtlConsent) String() string {
+	var sb strings.Builder
+	sb.WriteString(strconv.Itoa(ac.Version))
+	sb.WriteByte('~')
+	writeIDs(&sb, ac.ConsentedProviders)
+	if ac.Version >= 2 && ac.DisclosedProviders != nil {
+		sb.WriteString("~")
+		sb.WriteString(disclosedPrefix)
+		writeIDs(&sb, ac.DisclosedProviders)
+	}
+	return sb.String()
+}
+
+// parseIDs parses IDs separated by dots, an empty string being an empty list
+func parseIDs(s string) ([]int, error) {
+	if s == "" {
+		return []int{}, nil
+	}
+	fields := strings.Split(s, ".")
+	ids := make([]int, 0, len(fields))
+	for _, field := range fields {
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q", field)
+		}
+		if id < 1 {
+			return nil, fmt.Errorf("id %d must be positive", id)
+		}
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+func writeIDs(sb *strings.Builder, ids []int) {
+	for i, id := range ids {
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(strconv.Itoa(id))
+	}
+}
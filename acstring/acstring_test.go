@@ -0,0 +1,118 @@
+package acstring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+
+	type TestCase struct {
+		value      string
+		want       *AddtlConsent
+		wantErr    string
+		wantString string
+	}
+
+	testCases := map[string]*TestCase{
+		"version-1": {
+			value:      "1~1.35.41.101",
+			want:       &AddtlConsent{Version: 1, ConsentedProviders: []int{1, 35, 41, 101}},
+			wantString: "1~1.35.41.101",
+		},
+		"version-2": {
+			value:      "2~1.35.41.101~dv.9.21.81",
+			want:       &AddtlConsent{Version: 2, ConsentedProviders: []int{1, 35, 41, 101}, DisclosedProviders: []int{9, 21, 81}},
+			wantString: "2~1.35.41.101~dv.9.21.81",
+		},
+		"version-2-without-disclosed": {
+			value:      "2~1.35",
+			want:       &AddtlConsent{Version: 2, ConsentedProviders: []int{1, 35}},
+			wantString: "2~1.35",
+		},
+		"no-consent": {
+			value:      "2~~dv.9",
+			want:       &AddtlConsent{Version: 2, ConsentedProviders: []int{}, DisclosedProviders: []int{9}},
+			wantString: "2~~dv.9",
+		},
+		"empty-disclosed": {
+			value:      "2~1~dv.",
+			want:       &AddtlConsent{Version: 2, ConsentedProviders: []int{1}, DisclosedProviders: []int{}},
+			wantString: "2~1~dv.",
+		},
+		"unsorted": {
+			value:      "1~41.1.41",
+			want:       &AddtlConsent{Version: 1, ConsentedProviders: []int{1, 41}},
+			wantString: "1~1.41",
+		},
+		"empty": {
+			value:   "",
+			wantErr: "ac string is empty",
+		},
+		"invalid-version": {
+			value:   "x~1",
+			wantErr: `version parse failed: strconv.Atoi: parsing "x": invalid syntax`,
+		},
+		"unsupported-version": {
+			value:   "3~1",
+			wantErr: "unsupported version 3",
+		},
+		"missing-consented": {
+			value:   "1",
+			wantErr: "consented providers are missing",
+		},
+		"version-1-with-disclosed": {
+			value:   "1~1~dv.2",
+			wantErr: "too many parts for version 1",
+		},
+		"invalid-consented-id": {
+			value:   "2~1.a~dv.2",
+			wantErr: `consented providers parse failed: invalid id "a"`,
+		},
+		"zero-id": {
+			value:   "2~1.0",
+			wantErr: "consented providers parse failed: id 0 must be positive",
+		},
+		"invalid-disclosed-prefix": {
+			value:   "2~1~2.3",
+			wantErr: `disclosed providers must start with "dv."`,
+		},
+		"invalid-disclosed-id": {
+			value:   "2~1~dv.2..3",
+			wantErr: `disclosed providers parse failed: invalid id ""`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(tc.value)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, tc.want, got)
+			require.Equal(t, tc.wantString, got.String())
+
+			// note: the string returned is parsed back into the same AC string
+			back, err := Parse(got.String())
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, got, back)
+		})
+	}
+}
+
+func TestProviderAllowed(t *testing.T) {
+
+	ac, err := Parse("2~1.35.41.101~dv.9.21.81")
+	require.NoError(t, err, "unexpected error")
+
+	require.True(t, ac.ProviderAllowed(35))
+	require.False(t, ac.ProviderAllowed(9), "disclosed provider is not allowed")
+	require.False(t, ac.ProviderAllowed(2))
+
+	require.True(t, ac.ProviderDisclosed(9))
+	require.True(t, ac.ProviderDisclosed(35), "consented provider is disclosed")
+	require.False(t, ac.ProviderDisclosed(2))
+}
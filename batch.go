@@ -0,0 +1,159 @@
+package iabtcf
+
+import (
+	"slices"
+	"sort"
+)
+
+// //////////////////////////////////////////////////
+// batch vendor evaluation
+//
+// note: checking many vendors at once walks the vendor section once, instead of once per vendor.
+// The IDs are sorted, then merged with the range entries, or looked up in the bitfield.
+
+// VendorsAllowed returns a bit set keyed by vendor ID, where the bit number of each allowed vendor of ids is set
+//
+// note: IDs lower than 1 or greater than 65535 are ignored, as vendor IDs are encoded on 16 bits
+func (p *Consent) VendorsAllowed(ids []int) Bits {
+	sorted, allowed := newBatch(ids)
+	if p.IsRangeEncoding {
+		entries, ok := p.normalized.of(p.RangeEntries)
+		if !ok {
+			// note: range entries set by hand may be unsorted, so the IDs of each entry are found by binary search
+			for _, e := range p.RangeEntries {
+				allowed.setRange(sorted, e.StartOrOnlyVendorId, e.EndVendorID)
+			}
+			return allowed
+		}
+
+		// note: the copy of the range entries normalized by the parser is sorted, so it's merged with the sorted IDs
+		i := 0
+		for _, e := range entries {
+			for i < len(sorted) && sorted[i] < e.StartOrOnlyVendorId {
+				i++
+			}
+			for i < len(sorted) && sorted[i] <= e.EndVendorID {
+				allowed.set(sorted[i])
+				i++
+			}
+			if i == len(sorted) {
+				break
+			}
+		}
+		return allowed
+	}
+
+	for _, id := range sorted {
+		if p.ConsentedVendors.HasBit(id) {
+			allowed.set(id)
+		}
+	}
+	return allowed
+}
+
+// FilterAllowed returns the allowed vendors of ids, in the same order
+func (p *Consent) FilterAllowed(ids []int) []int {
+	return filterIDs(ids, p.VendorsAllowed(ids))
+}
+
+// VendorsAllowed returns a bit set keyed by vendor ID, where the bit number of each allowed vendor of ids is set
+//
+// note: IDs lower than 1 or greater than 65535 are ignored, as vendor IDs are encoded on 16 bits
+// note: as for VendorAllowed, if the consent string is too short or invalid, the vendors are considered as not allowed
+func (c *LazyConsent) VendorsAllowed(ids []int) Bits {
+	sorted, allowed := newBatch(ids)
+	if len(sorted) == 0 {
+		return allowed
+	}
+
+	if !c.IsRangeEncoding() {
+		maxVendorID := c.MaxVendorID()
+		for _, id := range sorted {
+			if c.Core.ReadBitNumber(id, ConsentedVendorsOffset, maxVendorID) {
+				allowed.set(id)
+			}
+		}
+		return allowed
+	}
+
+	numEntries := c.NumRangeEntries()
+	if numEntries > rangeIndexThreshold {
+		// note: the index is sorted, so it's merged with the sorted IDs
+		i := 0
+		for _, packed := range c.rangeIndex() {
+			start, end := int(packed&0xFFFF), int(packed>>16)
+			for i < len(sorted) && sorted[i] < start {
+				i++
+			}
+			for i < len(sorted) && sorted[i] <= end {
+				allowed.set(sorted[i])
+				i++
+			}
+			if i == len(sorted) {
+				break
+			}
+		}
+		return allowed
+	}
+
+	// note: encoded range entries may be unsorted, so the IDs of each entry are found by binary search
+	offset := NumRangeEntriesField.NextOffset()
+	for range numEntries {
+		if offset >= c.Core.Length() {
+			break
+		}
+		isRange := c.Core.ReadBoolField(offset)
+		offset++
+		start := c.Core.ReadIntField(offset, 16)
+		offset += 16
+		end := start
+		if isRange {
+			end = c.Core.ReadIntField(offset, 16)
+			offset += 16
+		}
+		allowed.setRange(sorted, start, end)
+	}
+	return allowed
+}
+
+// FilterAllowed returns the allowed vendors of ids, in the same order
+func (c *LazyConsent) FilterAllowed(ids []int) []int {
+	return filterIDs(ids, c.VendorsAllowed(ids))
+}
+
+// newBatch returns the IDs which can be vendor IDs sorted, and an empty bit set large enough for all of them
+func newBatch(ids []int) ([]int, Bits) {
+	sorted := ids
+	if !slices.IsSorted(sorted) {
+		sorted = slices.Sorted(slices.Values(ids))
+	}
+	// note: IDs lower than 1 or greater than maxID are skipped, they are at both ends once sorted
+	sorted = sorted[sort.SearchInts(sorted, 1):sort.SearchInts(sorted, maxID+1)]
+	if len(sorted) == 0 {
+		return nil, Bits{}
+	}
+	return sorted, make(Bits, (sorted[len(sorted)-1]+lastBitIndex)/nbBitInByte)
+}
+
+// set sets the bit number id
+func (b Bits) set(id int) {
+	b[(id-1)/nbBitInByte] |= bitMasks[(id-1)%nbBitInByte]
+}
+
+// setRange sets the bit number of each of the sorted IDs between start and end
+func (b Bits) setRange(sorted []int, start, end int) {
+	for i := sort.SearchInts(sorted, start); i < len(sorted) && sorted[i] <= end; i++ {
+		b.set(sorted[i])
+	}
+}
+
+// filterIDs returns the IDs whose bit number is set, in the same order
+func filterIDs(ids []int, allowed Bits) []int {
+	res := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if allowed.HasBit(id) {
+			res = append(res, id)
+		}
+	}
+	return res
+}
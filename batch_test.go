@@ -0,0 +1,130 @@
+package iabtcf
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVendorsAllowed(t *testing.T) {
+
+	big := "CP9Qr_AP9Qr_AAfETDFRAwEsAP_gAEPgAAigg1NX_H__bX9v-Xr36ft0eY1f99j77uQxBhfJs-4FzLvW_JwX32EzNE36tqYKmRIEu3bBIQFtHJnUTVihaogVrzHsYkGchTNKJ-BkiHMRe2dYCF5vmYtj-QKZ5_p_d3f52T_9_dv-3dzzz91nv3f9f-f1eLida59tH_v_bRKb-_If9_7-_4v0_t_rk2_eTVv_9evv79-u_t____9_9____4"
+
+	// note: range entries are encoded unsorted, and both below and above the index threshold
+	unsortedRange := func(numEntries int) string {
+		entries := make([]RangeEntry, 0, numEntries)
+		for i := numEntries; i > 0; i-- {
+			entries = append(entries, RangeEntry{StartOrOnlyVendorId: 7 * i, EndVendorID: 7*i + i%3})
+		}
+		c, err := EncodeCoreString(&Consent{
+			Version:         2,
+			ConsentLanguage: "EN",
+			PublisherCC:     "AA",
+			MaxVendorID:     7*numEntries + 2,
+			IsRangeEncoding: true,
+			RangeEntries:    entries,
+		})
+		require.NoError(t, err, "unexpected encode error")
+		return c
+	}
+
+	tests := map[string]string{
+		"small-range":    "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA",
+		"bitfield":       big,
+		"unsorted-10":    unsortedRange(10),
+		"unsorted-200":   unsortedRange(200),
+		"truncated-core": "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTg",
+	}
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+	ids := []int{0, -3, 1, 1, 423}
+	for range 300 {
+		ids = append(ids, rnd.IntN(1500))
+	}
+
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			lazy, err := LazyParseCoreString(c)
+			require.NoError(t, err, "unexpected lazy parse error")
+			views := []ConsentView{lazy}
+			if eager, err := ParseCoreString(c); err == nil {
+				views = append(views, eager)
+			}
+
+			for _, v := range views {
+				var wantIDs []int
+				for _, id := range ids {
+					if id >= 1 && v.VendorAllowed(id) {
+						wantIDs = append(wantIDs, id)
+					}
+				}
+				want := FromIDs(wantIDs)
+
+				got := v.VendorsAllowed(ids)
+				require.True(t, want.Equal(got), "%T: want %v, got %v", v, want.ToIDs(), got.ToIDs())
+				require.Equal(t, wantIDs, nilIfEmpty(v.FilterAllowed(ids)), "%T", v)
+			}
+		})
+	}
+}
+
+func TestVendorsAllowedEmpty(t *testing.T) {
+	lazy, err := LazyParseCoreString("COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA")
+	require.NoError(t, err, "unexpected lazy parse error")
+
+	require.Empty(t, lazy.VendorsAllowed(nil))
+	require.Empty(t, lazy.VendorsAllowed([]int{0, -1}))
+	require.Empty(t, lazy.FilterAllowed(nil))
+}
+
+func TestVendorsAllowedHugeIDs(t *testing.T) {
+	c := "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA"
+	lazy, err := LazyParseCoreString(c)
+	require.NoError(t, err, "unexpected lazy parse error")
+	eager, err := ParseCoreString(c)
+	require.NoError(t, err, "unexpected parse error")
+
+	// note: IDs which can't be vendor IDs are ignored, instead of sizing the bit set after them
+	ids := []int{1 << 36, 423, maxID + 1, math.MaxInt}
+	for _, v := range []ConsentView{lazy, eager} {
+		require.Equal(t, []int{423}, v.VendorsAllowed(ids).ToIDs(), "%T", v)
+		require.Equal(t, []int{423}, v.FilterAllowed(ids), "%T", v)
+		require.Empty(t, v.VendorsAllowed([]int{math.MaxInt}), "%T", v)
+	}
+}
+
+func nilIfEmpty(ids []int) []int {
+	if len(ids) == 0 {
+		return nil
+	}
+	return ids
+}
+
+func BenchmarkVendorsAllowed(b *testing.B) {
+
+	ids := make([]int, 300)
+	for i := range ids {
+		ids[i] = 3*i + 1
+	}
+
+	for _, numEntries := range []int{10, 1000} {
+		consent, err := LazyParseCoreString(rangeEncodedConsent(b, numEntries))
+		require.NoError(b, err, "unexpected parse error")
+
+		b.Run(fmt.Sprintf("range-%d-entries-batch", numEntries), func(b *testing.B) {
+			for b.Loop() {
+				_ = consent.VendorsAllowed(ids)
+			}
+		})
+		b.Run(fmt.Sprintf("range-%d-entries-one-by-one", numEntries), func(b *testing.B) {
+			for b.Loop() {
+				for _, id := range ids {
+					_ = consent.VendorAllowed(id)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package iabtcf
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// //////////////////////////////////////////////////
+// benchmarks of both parsers over a corpus of realistic consent strings
+//
+// note: every benchmark parses the string, since the cost of the lazy parser is moved from the parse to the accesses.
+// Run them with: go test -run '^$' -bench Corpus -benchmem
+
+type benchmarkConsent struct {
+	name string
+	s    string
+}
+
+// benchmarkCorpus returns consent strings as produced by CMPs
+func benchmarkCorpus(tb testing.TB) []benchmarkConsent {
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	purposes := FromIDs([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+
+	newConsent := func() *Consent {
+		return &Consent{
+			Version:                2,
+			Created:                created,
+			LastUpdated:            created,
+			CMPID:                  31,
+			CMPVersion:             4,
+			ConsentLanguage:        "EN",
+			VendorListVersion:      245,
+			TcfPolicyVersion:       4,
+			IsServiceSpecific:      true,
+			SpecialFeatureOptIns:   FromIDs([]int{1}),
+			PurposesConsent:        purposes,
+			PurposesLITransparency: FromIDs([]int{2, 7, 9, 10}),
+			PublisherCC:            "DE",
+		}
+	}
+
+	// note: a publisher working with a few vendors, encoded as bitfields
+	short := newConsent()
+	short.MaxVendorID = 120
+	short.ConsentedVendors = FromIDs([]int{1, 2, 10, 21, 32, 52, 69, 76, 91, 120})
+	short.VendorLegitimateInterests = VendorSection{MaxVendorID: 91, BitField: FromIDs([]int{10, 52, 91})}
+
+	// note: user has accepted every vendor of the global vendor list, but some IDs are not assigned,
+	// so the consented vendors are encoded as range entries
+	large := newConsent()
+	large.MaxVendorID = 4000
+	large.IsRangeEncoding = true
+	for start := 1; start <= 4000; start += 4 {
+		large.RangeEntries = append(large.RangeEntries, RangeEntry{StartOrOnlyVendorId: start, EndVendorID: min(start+2, 4000)})
+	}
+	large.VendorLegitimateInterests = VendorSection{MaxVendorID: 4000, IsRangeEncoding: true, RangeEntries: []RangeEntry{
+		{StartOrOnlyVendorId: 1, EndVendorID: 1200},
+		{StartOrOnlyVendorId: 1500, EndVendorID: 4000},
+	}}
+	large.PublisherRestrictions = []PublisherRestriction{{PurposeID: 2, RestrictionType: RestrictionRequireConsent, RangeEntries: []RangeEntry{{StartOrOnlyVendorId: 755, EndVendorID: 755}}}}
+
+	withSegments := func(p *Consent) *Consent {
+		clone := p.Clone()
+		ids := make([]int, 0, p.MaxVendorID)
+		for id := 1; id <= p.MaxVendorID; id++ {
+			ids = append(ids, id)
+		}
+		clone.DisclosedVendorsSegment = &VendorSection{MaxVendorID: p.MaxVendorID, BitField: FromIDs(ids)}
+		clone.PublisherTC = &PublisherTC{
+			PubPurposesConsent:        purposes,
+			PubPurposesLITransparency: FromIDs([]int{2}),
+			NumCustomPurposes:         2,
+			CustomPurposesConsent:     FromIDs([]int{1}),
+		}
+		return clone
+	}
+
+	corpus := []benchmarkConsent{}
+	for name, p := range map[string]*Consent{
+		"short-bitfield":          short,
+		"short-bitfield-segments": withSegments(short),
+		"range-4000":              large,
+		"range-4000-segments":     withSegments(large),
+	} {
+		s, err := EncodeCoreString(p)
+		require.NoError(tb, err, "unexpected encode error")
+		corpus = append(corpus, benchmarkConsent{name: name, s: s})
+	}
+	slices.SortFunc(corpus, func(a, b benchmarkConsent) int { return strings.Compare(a.name, b.name) })
+	return corpus
+}
+
+// benchmarkVendorIDs are the vendors checked by the multi-vendor benchmarks, as an SSP checks its bidders
+var benchmarkVendorIDs = []int{1, 10, 21, 32, 52, 69, 76, 91, 120, 142, 253, 423, 755, 804, 1126, 1500, 2000, 2501, 3333, 3999}
+
+// benchmarkParsers runs f on every string of the corpus, with both parsers
+func benchmarkParsers(b *testing.B, eager func(b *testing.B, p *Consent), lazy func(b *testing.B, c *LazyConsent)) {
+	for _, tc := range benchmarkCorpus(b) {
+		b.Run(tc.name+"/eager", func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(tc.s)))
+			for b.Loop() {
+				p, err := ParseCoreString(tc.s)
+				if err != nil {
+					b.Fatal(err)
+				}
+				eager(b, p)
+			}
+		})
+		b.Run(tc.name+"/lazy", func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(tc.s)))
+			for b.Loop() {
+				c, err := LazyParseCoreString(tc.s)
+				if err != nil {
+					b.Fatal(err)
+				}
+				lazy(b, c)
+			}
+		})
+	}
+}
+
+func BenchmarkCorpusParse(b *testing.B) {
+	benchmarkParsers(b, func(*testing.B, *Consent) {}, func(*testing.B, *LazyConsent) {})
+}
+
+func BenchmarkCorpusSingleVendor(b *testing.B) {
+	purposes := []int{1, 2, 7}
+	benchmarkParsers(b,
+		func(b *testing.B, p *Consent) {
+			if !p.VendorAllowed(21) || !p.EveryPurposeAllowed(purposes) {
+				b.Fatal("vendor 21 must be allowed")
+			}
+		},
+		func(b *testing.B, c *LazyConsent) {
+			if !c.VendorAllowed(21) || !c.EveryPurposeAllowed(purposes) {
+				b.Fatal("vendor 21 must be allowed")
+			}
+		},
+	)
+}
+
+func BenchmarkCorpusMultiVendor(b *testing.B) {
+	benchmarkParsers(b,
+		func(b *testing.B, p *Consent) {
+			for _, id := range benchmarkVendorIDs {
+				_ = p.VendorAllowed(id)
+			}
+		},
+		func(b *testing.B, c *LazyConsent) {
+			for _, id := range benchmarkVendorIDs {
+				_ = c.VendorAllowed(id)
+			}
+		},
+	)
+}
+
+func BenchmarkCorpusMultiVendorBatch(b *testing.B) {
+	benchmarkParsers(b,
+		func(b *testing.B, p *Consent) { _ = p.VendorsAllowed(benchmarkVendorIDs) },
+		func(b *testing.B, c *LazyConsent) { _ = c.VendorsAllowed(benchmarkVendorIDs) },
+	)
+}
+
+// note: the full field access reads every core field, and iterates over every vendor list
+func BenchmarkCorpusAllFields(b *testing.B) {
+	benchmarkParsers(b,
+		func(b *testing.B, p *Consent) {
+			n := p.Version + p.CMPID + p.CMPVersion + p.ConsentScreen + p.VendorListVersion + p.TcfPolicyVersion + p.MaxVendorID
+			n += len(p.ConsentLanguage) + len(p.PublisherCC) + p.Created.Second() + p.LastUpdated.Second()
+			_ = p.IsServiceSpecific || p.UseNonStandardStacks || p.PurposeOneTreatment
+			for range p.AllowedPurposes() {
+				n++
+			}
+			for range p.ConsentedVendorIDs() {
+				n++
+			}
+			for range p.LIVendors() {
+				n++
+			}
+			for range p.DisclosedVendors() {
+				n++
+			}
+			if n == 0 {
+				b.Fatal("no field read")
+			}
+		},
+		func(b *testing.B, c *LazyConsent) {
+			n := c.Version() + c.CMPID() + c.CMPVersion() + c.ConsentScreen() + c.VendorListVersion() + c.TcfPolicyVersion() + c.MaxVendorID()
+			n += len(c.ConsentLanguage()) + len(c.PublisherCC()) + c.Created().Second() + c.LastUpdated().Second()
+			_ = c.IsServiceSpecific() || c.UseNonStandardStacks() || c.PurposeOneTreatment()
+			for range c.AllowedPurposes() {
+				n++
+			}
+			for range c.ConsentedVendorIDs() {
+				n++
+			}
+			for range c.LIVendors() {
+				n++
+			}
+			for range c.DisclosedVendors() {
+				n++
+			}
+			if n == 0 {
+				b.Fatal("no field read")
+			}
+		},
+	)
+}
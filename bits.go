@@ -1,7 +1,12 @@
 package iabtcf
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"iter"
+	"math/bits"
+	"slices"
 	"time"
 )
 
@@ -31,6 +36,8 @@ func (b Bits) Length() int {
 const (
 	nbBitInByte  = 8
 	lastBitIndex = nbBitInByte - 1
+	nbByteInWord = 8
+	nbBitInWord  = nbByteInWord * nbBitInByte
 )
 
 var (
@@ -46,20 +53,37 @@ var (
 	}
 )
 
+// maxWindowNbBits is the maximum number of bits read from a single 64 bits window,
+// since the field may start at any of the 8 bits of the first byte of the window
+const maxWindowNbBits = nbBitInWord - lastBitIndex
+
 // ReadInt64Field reads an int64 field of nbBits bits starting at offset
 //
 // note: if offset is negative, the result will be zero
 // note: if offset + nbBits is out of bound, the result will be the same if we were adding trailing zeros
 // example: 00101 > read with offset 2 and nbBits 5 > equivalent to reading 10100 = 20
+//
+// note: the field is read with a single big-endian load of the 64 bits window holding it, then shifted into place.
+// Fields longer than 57 bits are read bit by bit.
 func (b Bits) ReadInt64Field(offset, nbBits int) int64 {
-	if offset < 0 {
+	if offset < 0 || nbBits <= 0 {
 		return 0
 	}
-	var result int64
 	byteIndex := offset / nbBitInByte
 	if byteIndex >= len(b) {
-		return result
+		return 0
 	}
+	if nbBits > maxWindowNbBits {
+		return b.readInt64FieldByBit(offset, nbBits)
+	}
+	w := b.word(byteIndex) << (offset % nbBitInByte)
+	return int64(w >> (nbBitInWord - nbBits))
+}
+
+// readInt64FieldByBit reads an int64 field of nbBits bits starting at offset, one bit at a time
+func (b Bits) readInt64FieldByBit(offset, nbBits int) int64 {
+	var result int64
+	byteIndex := offset / nbBitInByte
 	bitIndex := offset % nbBitInByte
 	for i := 0; i < nbBits; i++ {
 		mask := bitMasks[bitIndex]
@@ -141,6 +165,28 @@ func (bits Bits) ToBitString() string {
 	return result
 }
 
+// ReadFibonacciIntField reads a Fibonacci encoded integer starting at offset ( terminated by two consecutive 1 bits ),
+// and returns its value and its number of bits
+//
+// note: if the terminator is not found within 64 bits or before the end of the bitset, the number of bits is 0
+func (b *Bits) ReadFibonacciIntField(offset int) (int, int) {
+	value := 0
+	fib, nextFib := 1, 2
+	previous := false
+	for i := 0; i < maxFibonacciNbBits && offset+i < b.Length(); i++ {
+		bit := b.ReadBoolField(offset + i)
+		if bit && previous {
+			return value, i + 1
+		}
+		if bit {
+			value += fib
+		}
+		previous = bit
+		fib, nextFib = nextFib, fib+nextFib
+	}
+	return 0, 0
+}
+
 // ReadBitNumber reads bit number as bool and checks boundaries
 func (b *Bits) ReadBitNumber(number, offset, maxNbbBits int) bool {
 	if b == nil || number < 1 || number > maxNbbBits {
@@ -149,6 +195,131 @@ func (b *Bits) ReadBitNumber(number, offset, maxNbbBits int) bool {
 	return b.ReadBoolField(offset + number - 1)
 }
 
+// //////////////////////////////////////////////////
+// bit set operations
+//
+// note: bit sets are used as sets of IDs, where ID n is stored in bit number n.
+// Operations are done one 64 bits word at a time, and missing bytes are considered as zeros.
+
+// maxID is the highest ID which can be encoded, the ID fields having 16 bits
+const maxID = 1<<16 - 1
+
+// FromIDs returns a bit set with the bit number of each ID set
+//
+// note: IDs lower than 1 or greater than 65535 are ignored
+func FromIDs(ids []int) Bits {
+	highest := 0
+	for _, id := range ids {
+		if id <= maxID {
+			highest = max(highest, id)
+		}
+	}
+	b := make(Bits, (highest+lastBitIndex)/nbBitInByte)
+	for _, id := range ids {
+		if id >= 1 && id <= maxID {
+			b[(id-1)/nbBitInByte] |= bitMasks[(id-1)%nbBitInByte]
+		}
+	}
+	return b
+}
+
+// ToIDs returns the numbers of the bits set, in ascending order
+//
+// note: the result is never nil
+func (b Bits) ToIDs() []int {
+	return slices.AppendSeq(make([]int, 0, b.Count()), b.All())
+}
+
+// All returns an iterator over the numbers of the bits set, in ascending order
+func (b Bits) All() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := 0; i < len(b); i += nbByteInWord {
+			w := b.word(i)
+			for w != 0 {
+				index := bits.LeadingZeros64(w)
+				if !yield(i*nbBitInByte + index + 1) {
+					return
+				}
+				w &^= 1 << (nbBitInWord - 1 - index)
+			}
+		}
+	}
+}
+
+// Count returns the number of bits set
+func (b Bits) Count() int {
+	count := 0
+	for i := 0; i < len(b); i += nbByteInWord {
+		count += bits.OnesCount64(b.word(i))
+	}
+	return count
+}
+
+// And returns the bits set in both b and o
+func (b Bits) And(o Bits) Bits {
+	result := make(Bits, min(len(b), len(o)))
+	for i := 0; i < len(result); i += nbByteInWord {
+		result.putWord(i, b.word(i)&o.word(i))
+	}
+	return result
+}
+
+// Or returns the bits set in b or o
+func (b Bits) Or(o Bits) Bits {
+	result := make(Bits, max(len(b), len(o)))
+	for i := 0; i < len(result); i += nbByteInWord {
+		result.putWord(i, b.word(i)|o.word(i))
+	}
+	return result
+}
+
+// AndNot returns the bits set in b and not in o
+func (b Bits) AndNot(o Bits) Bits {
+	result := make(Bits, len(b))
+	for i := 0; i < len(result); i += nbByteInWord {
+		result.putWord(i, b.word(i)&^o.word(i))
+	}
+	return result
+}
+
+// Equal checks if b and o have the same bits set
+//
+// note: trailing zeros are ignored, so bit sets of different lengths can be equal
+func (b Bits) Equal(o Bits) bool {
+	n := min(len(b), len(o))
+	if !bytes.Equal(b[:n], o[:n]) {
+		return false
+	}
+	return b[n:].Count() == 0 && o[n:].Count() == 0
+}
+
+// word returns the 64 bits starting at byte index i
+//
+// note: missing bytes are read as zeros
+func (b Bits) word(i int) uint64 {
+	if i+nbByteInWord <= len(b) {
+		return binary.BigEndian.Uint64(b[i:])
+	}
+	var buf [nbByteInWord]byte
+	if i < len(b) {
+		copy(buf[:], b[i:])
+	}
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+// putWord writes the 64 bits starting at byte index i
+//
+// note: bytes out of bound are not written
+func (b Bits) putWord(i int, w uint64) {
+	if i+nbByteInWord <= len(b) {
+		binary.BigEndian.PutUint64(b[i:], w)
+		return
+	}
+	var buf [nbByteInWord]byte
+	binary.BigEndian.PutUint64(buf[:], w)
+	copy(b[i:], buf[:])
+}
+
 // //////////////////////////////////////////////////
 // bit string helper
 
@@ -1,6 +1,7 @@
 package iabtcf
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"strings"
@@ -101,3 +102,103 @@ func TestBits(t *testing.T) {
 		})
 	}
 }
+
+func TestBitsSetOperations(t *testing.T) {
+
+	// note: more than 8 bytes to exercise both full words and trailing bytes
+	a := FromIDs([]int{1, 3, 8, 64, 65, 100})
+	b := FromIDs([]int{3, 4, 64, 100, 130})
+
+	require.Equal(t, []int{1, 3, 8, 64, 65, 100}, a.ToIDs())
+	require.Equal(t, 6, a.Count())
+	require.Equal(t, 5, b.Count())
+
+	require.Equal(t, []int{3, 64, 100}, a.And(b).ToIDs())
+	require.Equal(t, []int{1, 3, 4, 8, 64, 65, 100, 130}, a.Or(b).ToIDs())
+	require.Equal(t, []int{1, 8, 65}, a.AndNot(b).ToIDs())
+	require.Equal(t, []int{4, 130}, b.AndNot(a).ToIDs())
+
+	require.True(t, a.Equal(a.Or(nil)))
+	require.True(t, a.Equal(append(FromIDs([]int{1, 3, 8, 64, 65, 100}), 0, 0, 0)), "trailing zeros are ignored")
+	require.False(t, a.Equal(b))
+	require.False(t, a.Equal(a.AndNot(FromIDs([]int{100}))))
+
+	require.Equal(t, []int{}, Bits(nil).ToIDs())
+	require.Equal(t, 0, Bits(nil).Count())
+	require.True(t, Bits(nil).Equal(Bits{0, 0}))
+	require.Equal(t, []int{2}, FromIDs([]int{0, -1, 2}).ToIDs(), "ids lower than 1 are ignored")
+	require.Equal(t, Bits{0x40}, FromIDs([]int{2, 1 << 16, 1 << 40}), "ids greater than 65535 are ignored")
+
+	for number := 1; number <= 140; number++ {
+		require.Equal(t, a.HasBit(number) && b.HasBit(number), a.And(b).HasBit(number), "and %d", number)
+	}
+
+	// early stop
+	for id := range a.All() {
+		require.Equal(t, 1, id)
+		break
+	}
+}
+
+func TestBitsFibonacci(t *testing.T) {
+
+	tests := []struct {
+		bits       string
+		offset     int
+		wantValue  int
+		wantNbBits int
+	}{
+		{bits: "11", wantValue: 1, wantNbBits: 2},
+		{bits: "011", wantValue: 2, wantNbBits: 3},
+		{bits: "1011", wantValue: 4, wantNbBits: 4},
+		{bits: "10011", wantValue: 6, wantNbBits: 5},
+		{bits: "0110011", offset: 3, wantValue: 3, wantNbBits: 4},
+		{bits: "00000000", wantValue: 0, wantNbBits: 0},
+	}
+
+	for i, tt := range tests {
+		b := BitStringToBits(tt.bits)
+		value, nbBits := b.ReadFibonacciIntField(tt.offset)
+		require.Equal(t, tt.wantValue, value, "case %d", i)
+		require.Equal(t, tt.wantNbBits, nbBits, "case %d", i)
+	}
+}
+
+func TestReadInt64Field(t *testing.T) {
+
+	// note: the window read must match the bit by bit read, including out of bound reads
+	b := Bits{0xA5, 0x3C, 0xFF, 0x00, 0x81, 0x7E, 0x42, 0x99, 0xC3, 0x18, 0x01}
+	for offset := -1; offset <= b.Length()+1; offset++ {
+		for nbBits := 0; nbBits <= 64; nbBits++ {
+			want := int64(0)
+			if offset >= 0 && offset/nbBitInByte < len(b) {
+				want = b.readInt64FieldByBit(offset, nbBits)
+			}
+			require.Equal(t, want, b.ReadInt64Field(offset, nbBits), "offset=%d nbBits=%d", offset, nbBits)
+		}
+	}
+}
+
+func BenchmarkReadInt64Field(b *testing.B) {
+	bits := Bits(bytes.Repeat([]byte{0xA5}, 64))
+	for _, nbBits := range []int{1, 16, 36} {
+		b.Run(fmt.Sprintf("%d-bits", nbBits), func(b *testing.B) {
+			var sum int64
+			for b.Loop() {
+				for offset := 0; offset < 256; offset += 3 {
+					sum += bits.ReadInt64Field(offset, nbBits)
+				}
+			}
+			_ = sum
+		})
+		b.Run(fmt.Sprintf("%d-bits-by-bit", nbBits), func(b *testing.B) {
+			var sum int64
+			for b.Loop() {
+				for offset := 0; offset < 256; offset += 3 {
+					sum += bits.readInt64FieldByBit(offset, nbBits)
+				}
+			}
+			_ = sum
+		})
+	}
+}
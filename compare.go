@@ -0,0 +1,136 @@
+package iabtcf
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+)
+
+// Mismatch is a field decoded differently by the eager and the lazy parsers
+//
+// note: for the vendor and purpose lists, Eager and Lazy are the IDs found by only one of the parsers.
+type Mismatch struct {
+	Field string
+	Eager any
+	Lazy  any
+}
+
+// String returns a readable representation of the mismatch
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: eager %v, lazy %v", m.Field, m.Eager, m.Lazy)
+}
+
+// CompareParsers parses the consent string with both ParseCoreString and LazyParseCoreString,
+// and returns the fields on which they disagree
+//
+// It's meant to run on samples of real traffic, to catch a divergence before it causes wrong consent decisions.
+// When only one of the parsers fails, the only mismatch is the "error" field. When both fail, nil is returned.
+//
+// The compared fields are the core fields, the purposes, the special features,
+// the consented, legitimate interest and disclosed vendors, and VendorAllowed for every vendor up to the max vendor ID.
+//
+// note: the Observer set by SetObserver, if any, is not notified.
+func CompareParsers(s string) []Mismatch {
+	p, eagerErr := parseCoreString(s)
+	c := &LazyConsent{}
+	lazyErr := lazyParseInto(c, s)
+	if eagerErr != nil || lazyErr != nil {
+		if eagerErr != nil && lazyErr != nil {
+			return nil
+		}
+		return []Mismatch{{Field: "error", Eager: errorValue(eagerErr), Lazy: errorValue(lazyErr)}}
+	}
+	return compareConsents(p, c)
+}
+
+// compareConsents returns the fields decoded differently in p and c
+func compareConsents(p *Consent, c *LazyConsent) []Mismatch {
+	var m mismatches
+	m.compare("version", p.Version, c.Version())
+	m.compare("created", p.Created, c.Created())
+	m.compare("last_updated", p.LastUpdated, c.LastUpdated())
+	m.compare("cmp_id", p.CMPID, c.CMPID())
+	m.compare("cmp_version", p.CMPVersion, c.CMPVersion())
+	m.compare("consent_screen", p.ConsentScreen, c.ConsentScreen())
+	m.compare("consent_language", p.ConsentLanguage, c.ConsentLanguage())
+	m.compare("vendor_list_version", p.VendorListVersion, c.VendorListVersion())
+	m.compare("tcf_policy_version", p.TcfPolicyVersion, c.TcfPolicyVersion())
+	m.compare("is_service_specific", p.IsServiceSpecific, c.IsServiceSpecific())
+	m.compare("use_non_standard_stacks", p.UseNonStandardStacks, c.UseNonStandardStacks())
+	m.compare("purpose_one_treatment", p.PurposeOneTreatment, c.PurposeOneTreatment())
+	m.compare("publisher_cc", p.PublisherCC, c.PublisherCC())
+	m.compare("max_vendor_id", p.MaxVendorID, c.MaxVendorID())
+	m.compare("is_range_encoding", p.IsRangeEncoding, c.IsRangeEncoding())
+	if p.IsRangeEncoding && c.IsRangeEncoding() {
+		m.compare("num_entries", p.NumEntries, c.NumRangeEntries())
+	}
+
+	m.compareIDs("purposes", p.AllowedPurposes(), c.AllowedPurposes())
+	m.compareIDs("purposes_li", numbersSeq(PurposesLITransparencyField.NbBits, p.PurposeLITransparencyAllowed), numbersSeq(PurposesLITransparencyField.NbBits, c.PurposeLITransparencyAllowed))
+	m.compareIDs("special_features", numbersSeq(SpecialFeatureOptInsField.NbBits, p.SpecialFeatureAllowed), numbersSeq(SpecialFeatureOptInsField.NbBits, c.SpecialFeatureAllowed))
+	m.compareIDs("vendors", p.ConsentedVendorIDs(), c.ConsentedVendorIDs())
+	m.compareIDs("li_vendors", p.LIVendors(), c.LIVendors())
+	m.compareIDs("disclosed_vendors", p.DisclosedVendors(), c.DisclosedVendors())
+
+	// note: the lookups are compared too, since they don't share the code of the iterators
+	maxVendorID := max(p.MaxVendorID, c.MaxVendorID())
+	m.compareIDs("vendor_allowed", numbersSeq(maxVendorID, p.VendorAllowed), numbersSeq(maxVendorID, c.VendorAllowed))
+	return m
+}
+
+type mismatches []Mismatch
+
+// compare appends a mismatch if the values are different
+func (m *mismatches) compare(field string, eager, lazy any) {
+	if t, ok := eager.(time.Time); ok {
+		if t.Equal(lazy.(time.Time)) {
+			return
+		}
+	} else if eager == lazy {
+		return
+	}
+	*m = append(*m, Mismatch{Field: field, Eager: eager, Lazy: lazy})
+}
+
+// compareIDs appends a mismatch with the IDs yielded by only one of the sorted iterators, if any
+func (m *mismatches) compareIDs(field string, eager, lazy iter.Seq[int]) {
+	eagerIDs, lazyIDs := slices.Collect(eager), slices.Collect(lazy)
+	var onlyEager, onlyLazy []int
+	i, j := 0, 0
+	for i < len(eagerIDs) || j < len(lazyIDs) {
+		switch {
+		case j == len(lazyIDs) || (i < len(eagerIDs) && eagerIDs[i] < lazyIDs[j]):
+			onlyEager = append(onlyEager, eagerIDs[i])
+			i++
+		case i == len(eagerIDs) || lazyIDs[j] < eagerIDs[i]:
+			onlyLazy = append(onlyLazy, lazyIDs[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	if onlyEager != nil || onlyLazy != nil {
+		*m = append(*m, Mismatch{Field: field, Eager: onlyEager, Lazy: onlyLazy})
+	}
+}
+
+// numbersSeq returns an iterator over the numbers from 1 to maxNumber for which allowed returns true
+func numbersSeq(maxNumber int, allowed func(number int) bool) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for number := 1; number <= maxNumber; number++ {
+			if allowed(number) && !yield(number) {
+				return
+			}
+		}
+	}
+}
+
+// errorValue returns the message of err, or nil
+func errorValue(err error) any {
+	if err == nil {
+		return nil
+	}
+	return err.Error()
+}
@@ -0,0 +1,74 @@
+package iabtcf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareParsers(t *testing.T) {
+
+	type TestCase struct {
+		consentString string
+		want          []Mismatch
+	}
+
+	testCases := map[string]*TestCase{
+		"range-encoding": {
+			consentString: "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA.IF5EX2S5OI2tho2YdF7BEYYwfJxyigMgShgQIsS8NwIeFbBoGPmAAHBG4JAQAGBAkkACBAQIsHGBcCQABgIgRiRCMQEGMjzNKBJBAggkbI0FACCVmnkHS3ZCY70-6u__bA",
+		},
+		"bitfield-with-segments": {
+			consentString: encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(testPublisherTCBits),
+		},
+		"both-fail": {
+			consentString: "",
+		},
+		"undecodable-segment": {
+			consentString: "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA.I!",
+		},
+		"truncated-after-vendor-consents": {
+			consentString: "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTg",
+		},
+		"truncated-vendor-consents": {
+			consentString: "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDT",
+			want: []Mismatch{{
+				Field: "error",
+				Eager: "range entries parse failed: ReadInt failed: ReadBits failed: read bits (index=243, length=16): bits: length extends beyond range",
+				Lazy:  nil,
+			}},
+		},
+	}
+
+	for _, tc := range benchmarkCorpus(t) {
+		testCases[tc.name] = &TestCase{consentString: tc.s}
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, CompareParsers(tc.consentString))
+		})
+	}
+}
+
+func TestCompareConsents(t *testing.T) {
+
+	c := encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits)
+	p, err := ParseCoreString(c)
+	require.NoError(t, err, "unexpected error")
+	lazy, err := LazyParseCoreString(c)
+	require.NoError(t, err, "unexpected error")
+
+	// note: the eager consent is altered to simulate a divergence
+	p.CMPID = 1
+	p.ConsentedVendors = FromIDs([]int{1, 2})
+	p.DisclosedVendorsSegment = nil
+
+	require.Equal(t, []Mismatch{
+		{Field: "cmp_id", Eager: 1, Lazy: 92},
+		{Field: "vendors", Eager: []int{2}, Lazy: []int{5}},
+		{Field: "disclosed_vendors", Eager: []int(nil), Lazy: []int{1, 2, 5}},
+		{Field: "vendor_allowed", Eager: []int{2}, Lazy: []int{5}},
+	}, compareConsents(p, lazy))
+
+	require.Equal(t, "cmp_id: eager 1, lazy 92", Mismatch{Field: "cmp_id", Eager: 1, Lazy: 92}.String())
+}
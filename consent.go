@@ -1,6 +1,9 @@
 package iabtcf
 
 import (
+	"cmp"
+	"slices"
+	"sort"
 	"time"
 )
 
@@ -28,6 +31,15 @@ type Consent struct {
 	ConsentedVendors       Bits
 	NumEntries             int
 	RangeEntries           []RangeEntry
+	normalized             normalizedEntries
+
+	VendorLegitimateInterests VendorSection
+	PublisherRestrictions     []PublisherRestriction
+
+	// optional segments, nil when not present in the consent string
+	DisclosedVendorsSegment *VendorSection
+	AllowedVendorsSegment   *VendorSection
+	PublisherTC             *PublisherTC
 }
 
 // RangeEntry defines a range groups of Vendor IDs who have been disclosed to a user
@@ -36,6 +48,144 @@ type RangeEntry struct {
 	EndVendorID         int
 }
 
+// NormalizeRangeEntries sorts the range entries, merges the overlapping or adjacent ones,
+// and drops the empty ones ( end lower than start ), so vendors can be looked up by binary search
+//
+// note: the entries are normalized in place. The parsers return the entries as encoded, and keep a normalized copy
+// for the lookups, so it's only needed to speed up the lookups of entries set by hand.
+func NormalizeRangeEntries(entries []RangeEntry) []RangeEntry {
+	entries = slices.DeleteFunc(entries, func(e RangeEntry) bool { return e.EndVendorID < e.StartOrOnlyVendorId })
+	if !slices.IsSortedFunc(entries, compareRangeEntries) {
+		slices.SortFunc(entries, compareRangeEntries)
+	}
+	merged := entries[:0]
+	for _, e := range entries {
+		if n := len(merged); n > 0 && e.StartOrOnlyVendorId <= merged[n-1].EndVendorID+1 {
+			merged[n-1].EndVendorID = max(merged[n-1].EndVendorID, e.EndVendorID)
+			continue
+		}
+		merged = append(merged, e)
+	}
+	return merged
+}
+
+func compareRangeEntries(a, b RangeEntry) int {
+	return cmp.Compare(a.StartOrOnlyVendorId, b.StartOrOnlyVendorId)
+}
+
+// normalizedEntries is a normalized copy of range entries, made once by the parsers so the lookups can binary search it
+//
+// note: it's only used while the range entries are the slice it has been made from,
+// so range entries set by hand are scanned. Range entries modified in place after parsing are not supported.
+type normalizedEntries struct {
+	from    []RangeEntry
+	entries []RangeEntry
+}
+
+// newNormalizedEntries returns the normalized copy of entries, which are not copied if already normalized
+func newNormalizedEntries(entries []RangeEntry) normalizedEntries {
+	if rangeEntriesNormalized(entries) {
+		return normalizedEntries{from: entries, entries: entries}
+	}
+	return normalizedEntries{from: entries, entries: NormalizeRangeEntries(slices.Clone(entries))}
+}
+
+// of returns the normalized copy of entries, false if it has not been made from entries
+func (n *normalizedEntries) of(entries []RangeEntry) ([]RangeEntry, bool) {
+	if len(entries) == 0 {
+		return nil, true
+	}
+	if len(n.from) != len(entries) || &n.from[0] != &entries[0] {
+		return nil, false
+	}
+	return n.entries, true
+}
+
+// rangeEntriesContain checks if the number is covered by one of the range entries
+//
+// note: the normalized copy of the entries is looked up by binary search, entries without one are scanned
+func rangeEntriesContain(entries []RangeEntry, normalized *normalizedEntries, number int) bool {
+	sorted, ok := normalized.of(entries)
+	if !ok {
+		return slices.ContainsFunc(entries, func(e RangeEntry) bool {
+			return e.StartOrOnlyVendorId <= number && number <= e.EndVendorID
+		})
+	}
+	i := sort.Search(len(sorted), func(i int) bool { return sorted[i].EndVendorID >= number })
+	return i < len(sorted) && sorted[i].StartOrOnlyVendorId <= number
+}
+
+// rangeEntriesNormalized checks if the range entries are sorted, disjoint and not empty, as NormalizeRangeEntries returns them
+func rangeEntriesNormalized(entries []RangeEntry) bool {
+	for i, e := range entries {
+		if e.EndVendorID < e.StartOrOnlyVendorId || (i > 0 && e.StartOrOnlyVendorId <= entries[i-1].EndVendorID) {
+			return false
+		}
+	}
+	return true
+}
+
+// VendorSection represents a list of vendors encoded either as a bitfield or as range entries
+//
+// It's used for the vendor legitimate interest section and for the disclosed and allowed vendors segments.
+type VendorSection struct {
+	MaxVendorID     int
+	IsRangeEncoding bool
+	BitField        Bits
+	NumEntries      int
+	RangeEntries    []RangeEntry
+	normalized      normalizedEntries
+}
+
+// HasVendor checks if vendor is in the section
+//
+// note: parsed range entries are looked up by binary search, range entries set by hand are scanned
+func (s *VendorSection) HasVendor(number int) bool {
+	if s == nil {
+		return false
+	}
+
+	if s.IsRangeEncoding {
+		return rangeEntriesContain(s.RangeEntries, &s.normalized, number)
+	}
+
+	return s.BitField.HasBit(number)
+}
+
+// RestrictionType defines how a publisher restricts the legal basis of a purpose for some vendors
+type RestrictionType int
+
+const (
+	RestrictionNotAllowed                RestrictionType = 0
+	RestrictionRequireConsent            RestrictionType = 1
+	RestrictionRequireLegitimateInterest RestrictionType = 2
+	RestrictionUndefined                 RestrictionType = 3
+)
+
+// PublisherRestriction defines a restriction type applied by the publisher to a purpose for a list of vendors
+type PublisherRestriction struct {
+	PurposeID       int
+	RestrictionType RestrictionType
+	NumEntries      int
+	RangeEntries    []RangeEntry
+}
+
+// PublisherTC represents the publisher purposes transparency and consent segment
+type PublisherTC struct {
+	PubPurposesConsent           Bits
+	PubPurposesLITransparency    Bits
+	NumCustomPurposes            int
+	CustomPurposesConsent        Bits
+	CustomPurposesLITransparency Bits
+}
+
+// segment types of the optional segments following the core string
+const (
+	disclosedVendorsSegmentType = 1
+	allowedVendorsSegmentType   = 2
+	publisherTCSegmentType      = 3
+)
+
 // EveryPurposeAllowed returns true if every purpose number is allowed in
 // the ParsedConsent, otherwise false
 func (p *Consent) EveryPurposeAllowed(numbers []int) bool {
@@ -74,16 +224,26 @@ func (p *Consent) SpecialFeatureAllowed(number int) bool {
 }
 
 // VendorAllowed checks if vendor is in the list of vendors user has given his consent to
+//
+// note: parsed range entries are looked up by binary search, range entries set by hand are scanned
 func (p *Consent) VendorAllowed(number int) bool {
 
 	if p.IsRangeEncoding {
-		for _, e := range p.RangeEntries {
-			if e.StartOrOnlyVendorId <= number && number <= e.EndVendorID {
-				return true
-			}
-		}
-		return false
+		return rangeEntriesContain(p.RangeEntries, &p.normalized, number)
 	}
 
 	return p.ConsentedVendors.HasBit(number)
 }
+
+// VendorLIAllowed checks if vendor is in the list of vendors for which legitimate interest is established
+func (p *Consent) VendorLIAllowed(number int) bool {
+	return p.VendorLegitimateInterests.HasVendor(number)
+}
+
+// VendorDisclosed checks if vendor is in the disclosed vendors segment
+//
+// note: returns false if there is no disclosed vendors segment.
+// As for LazyConsent.IsVendorDisclosed, vendors above the max vendor ID of the segment are not disclosed.
+func (p *Consent) VendorDisclosed(number int) bool {
+	return p.DisclosedVendorsSegment != nil && number <= p.DisclosedVendorsSegment.MaxVendorID && p.DisclosedVendorsSegment.HasVendor(number)
+}
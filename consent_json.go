@@ -0,0 +1,308 @@
+package iabtcf
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// consentJSON is the JSON representation of a Consent
+//
+// Schema:
+//   - purposes, special features and vendors are rendered as sorted arrays of IDs ( never null )
+//   - timestamps are rendered as RFC 3339 strings in UTC
+//   - disclosedVendors, allowedVendors and publisherTC are only present when the matching segment is present
+//
+// Example:
+//
+//	{
+//	  "version": 2,
+//	  "created": "2020-09-23T09:58:47.9Z",
+//	  "lastUpdated": "2020-09-23T09:58:47.9Z",
+//	  "cmpId": 92,
+//	  "cmpVersion": 0,
+//	  "consentScreen": 0,
+//	  "consentLanguage": "EN",
+//	  "vendorListVersion": 34,
+//	  "tcfPolicyVersion": 2,
+//	  "isServiceSpecific": false,
+//	  "useNonStandardStacks": false,
+//	  "specialFeatureOptIns": [1, 2],
+//	  "purposesConsent": [1, 2, 3],
+//	  "purposesLITransparency": [],
+//	  "purposeOneTreatment": false,
+//	  "publisherCC": "AA",
+//	  "vendorConsents": [423],
+//	  "vendorLegitimateInterests": [],
+//	  "publisherRestrictions": [{"purposeId": 1, "restrictionType": 0, "vendors": [1, 2, 3]}],
+//	  "disclosedVendors": [1, 2, 3, 423],
+//	  "publisherTC": {
+//	    "purposesConsent": [1],
+//	    "purposesLITransparency": [],
+//	    "numCustomPurposes": 2,
+//	    "customPurposesConsent": [2],
+//	    "customPurposesLITransparency": []
+//	  }
+//	}
+type consentJSON struct {
+	Version                   int                        `json:"version"`
+	Created                   time.Time                  `json:"created"`
+	LastUpdated               time.Time                  `json:"lastUpdated"`
+	CMPID                     int                        `json:"cmpId"`
+	CMPVersion                int                        `json:"cmpVersion"`
+	ConsentScreen             int                        `json:"consentScreen"`
+	ConsentLanguage           string                     `json:"consentLanguage"`
+	VendorListVersion         int                        `json:"vendorListVersion"`
+	TcfPolicyVersion          int                        `json:"tcfPolicyVersion"`
+	IsServiceSpecific         bool                       `json:"isServiceSpecific"`
+	UseNonStandardStacks      bool                       `json:"useNonStandardStacks"`
+	SpecialFeatureOptIns      []int                      `json:"specialFeatureOptIns"`
+	PurposesConsent           []int                      `json:"purposesConsent"`
+	PurposesLITransparency    []int                      `json:"purposesLITransparency"`
+	PurposeOneTreatment       bool                       `json:"purposeOneTreatment"`
+	PublisherCC               string                     `json:"publisherCC"`
+	VendorConsents            []int                      `json:"vendorConsents"`
+	VendorLegitimateInterests []int                      `json:"vendorLegitimateInterests"`
+	PublisherRestrictions     []publisherRestrictionJSON `json:"publisherRestrictions"`
+	DisclosedVendors          []int                      `json:"disclosedVendors,omitzero"`
+	AllowedVendors            []int                      `json:"allowedVendors,omitzero"`
+	PublisherTC               *publisherTCJSON           `json:"publisherTC,omitempty"`
+}
+
+type publisherRestrictionJSON struct {
+	PurposeID       int             `json:"purposeId"`
+	RestrictionType RestrictionType `json:"restrictionType"`
+	Vendors         []int           `json:"vendors"`
+}
+
+type publisherTCJSON struct {
+	PurposesConsent              []int `json:"purposesConsent"`
+	PurposesLITransparency       []int `json:"purposesLITransparency"`
+	NumCustomPurposes            int   `json:"numCustomPurposes"`
+	CustomPurposesConsent        []int `json:"customPurposesConsent"`
+	CustomPurposesLITransparency []int `json:"customPurposesLITransparency"`
+}
+
+// MarshalJSON renders the Consent using the documented schema ( see consentJSON )
+func (p *Consent) MarshalJSON() ([]byte, error) {
+	v := consentJSON{
+		Version:                   p.Version,
+		Created:                   p.Created.UTC(),
+		LastUpdated:               p.LastUpdated.UTC(),
+		CMPID:                     p.CMPID,
+		CMPVersion:                p.CMPVersion,
+		ConsentScreen:             p.ConsentScreen,
+		ConsentLanguage:           p.ConsentLanguage,
+		VendorListVersion:         p.VendorListVersion,
+		TcfPolicyVersion:          p.TcfPolicyVersion,
+		IsServiceSpecific:         p.IsServiceSpecific,
+		UseNonStandardStacks:      p.UseNonStandardStacks,
+		SpecialFeatureOptIns:      p.SpecialFeatureOptIns.ToIDs(),
+		PurposesConsent:           p.PurposesConsent.ToIDs(),
+		PurposesLITransparency:    p.PurposesLITransparency.ToIDs(),
+		PurposeOneTreatment:       p.PurposeOneTreatment,
+		PublisherCC:               p.PublisherCC,
+		VendorLegitimateInterests: p.VendorLegitimateInterests.ids(),
+		PublisherRestrictions:     make([]publisherRestrictionJSON, 0, len(p.PublisherRestrictions)),
+	}
+
+	if p.IsRangeEncoding {
+		v.VendorConsents = rangeEntriesToIDs(p.RangeEntries)
+	} else {
+		v.VendorConsents = p.ConsentedVendors.ToIDs()
+	}
+
+	for _, pr := range p.PublisherRestrictions {
+		v.PublisherRestrictions = append(v.PublisherRestrictions, publisherRestrictionJSON{
+			PurposeID:       pr.PurposeID,
+			RestrictionType: pr.RestrictionType,
+			Vendors:         rangeEntriesToIDs(pr.RangeEntries),
+		})
+	}
+
+	if p.DisclosedVendorsSegment != nil {
+		v.DisclosedVendors = p.DisclosedVendorsSegment.ids()
+	}
+	if p.AllowedVendorsSegment != nil {
+		v.AllowedVendors = p.AllowedVendorsSegment.ids()
+	}
+	if p.PublisherTC != nil {
+		v.PublisherTC = &publisherTCJSON{
+			PurposesConsent:              p.PublisherTC.PubPurposesConsent.ToIDs(),
+			PurposesLITransparency:       p.PublisherTC.PubPurposesLITransparency.ToIDs(),
+			NumCustomPurposes:            p.PublisherTC.NumCustomPurposes,
+			CustomPurposesConsent:        p.PublisherTC.CustomPurposesConsent.ToIDs(),
+			CustomPurposesLITransparency: p.PublisherTC.CustomPurposesLITransparency.ToIDs(),
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON builds the Consent from the documented schema ( see consentJSON )
+//
+// note: the JSON representation doesn't keep the vendor encoding type,
+// so every vendor section is rebuilt as a bitfield, and every publisher restriction as the smallest list of ranges.
+func (p *Consent) UnmarshalJSON(data []byte) error {
+	var v consentJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var err error
+	c := Consent{
+		Version:              v.Version,
+		Created:              v.Created.UTC(),
+		LastUpdated:          v.LastUpdated.UTC(),
+		CMPID:                v.CMPID,
+		CMPVersion:           v.CMPVersion,
+		ConsentScreen:        v.ConsentScreen,
+		ConsentLanguage:      v.ConsentLanguage,
+		VendorListVersion:    v.VendorListVersion,
+		TcfPolicyVersion:     v.TcfPolicyVersion,
+		IsServiceSpecific:    v.IsServiceSpecific,
+		UseNonStandardStacks: v.UseNonStandardStacks,
+		PurposeOneTreatment:  v.PurposeOneTreatment,
+		PublisherCC:          v.PublisherCC,
+	}
+	if c.SpecialFeatureOptIns, err = idsToBits(v.SpecialFeatureOptIns, SpecialFeatureOptInsField.NbBits); err != nil {
+		return fmt.Errorf("specialFeatureOptIns: %w", err)
+	}
+	if c.PurposesConsent, err = idsToBits(v.PurposesConsent, PurposesConsentField.NbBits); err != nil {
+		return fmt.Errorf("purposesConsent: %w", err)
+	}
+	if c.PurposesLITransparency, err = idsToBits(v.PurposesLITransparency, PurposesLITransparencyField.NbBits); err != nil {
+		return fmt.Errorf("purposesLITransparency: %w", err)
+	}
+
+	consented, err := idsToVendorSection(v.VendorConsents)
+	if err != nil {
+		return fmt.Errorf("vendorConsents: %w", err)
+	}
+	c.MaxVendorID = consented.MaxVendorID
+	c.ConsentedVendors = consented.BitField
+
+	if c.VendorLegitimateInterests, err = idsToVendorSection(v.VendorLegitimateInterests); err != nil {
+		return fmt.Errorf("vendorLegitimateInterests: %w", err)
+	}
+
+	c.PublisherRestrictions = make([]PublisherRestriction, 0, len(v.PublisherRestrictions))
+	for _, pr := range v.PublisherRestrictions {
+		entries, err := idsToRangeEntries(pr.Vendors)
+		if err != nil {
+			return fmt.Errorf("publisherRestrictions: %w", err)
+		}
+		c.PublisherRestrictions = append(c.PublisherRestrictions, PublisherRestriction{
+			PurposeID:       pr.PurposeID,
+			RestrictionType: pr.RestrictionType,
+			NumEntries:      len(entries),
+			RangeEntries:    entries,
+		})
+	}
+
+	if v.DisclosedVendors != nil {
+		s, err := idsToVendorSection(v.DisclosedVendors)
+		if err != nil {
+			return fmt.Errorf("disclosedVendors: %w", err)
+		}
+		c.DisclosedVendorsSegment = &s
+	}
+	if v.AllowedVendors != nil {
+		s, err := idsToVendorSection(v.AllowedVendors)
+		if err != nil {
+			return fmt.Errorf("allowedVendors: %w", err)
+		}
+		c.AllowedVendorsSegment = &s
+	}
+	if v.PublisherTC != nil {
+		tc := &PublisherTC{NumCustomPurposes: v.PublisherTC.NumCustomPurposes}
+		if tc.PubPurposesConsent, err = idsToBits(v.PublisherTC.PurposesConsent, 24); err != nil {
+			return fmt.Errorf("publisherTC.purposesConsent: %w", err)
+		}
+		if tc.PubPurposesLITransparency, err = idsToBits(v.PublisherTC.PurposesLITransparency, 24); err != nil {
+			return fmt.Errorf("publisherTC.purposesLITransparency: %w", err)
+		}
+		if tc.CustomPurposesConsent, err = idsToBits(v.PublisherTC.CustomPurposesConsent, tc.NumCustomPurposes); err != nil {
+			return fmt.Errorf("publisherTC.customPurposesConsent: %w", err)
+		}
+		if tc.CustomPurposesLITransparency, err = idsToBits(v.PublisherTC.CustomPurposesLITransparency, tc.NumCustomPurposes); err != nil {
+			return fmt.Errorf("publisherTC.customPurposesLITransparency: %w", err)
+		}
+		c.PublisherTC = tc
+	}
+
+	*p = c
+	return nil
+}
+
+// //////////////////////////////////////////////////
+// id list helpers
+
+// idsToBits returns a bitset of at least nbBits bits with the given bit numbers set
+//
+// note: the IDs are checked first, so the size of the bitset is bounded
+func idsToBits(ids []int, nbBits int) (Bits, error) {
+	for _, id := range ids {
+		if err := checkID(id); err != nil {
+			return nil, err
+		}
+	}
+	b := FromIDs(ids)
+	if n := (nbBits + lastBitIndex) / nbBitInByte; len(b) < n {
+		b = append(b, make(Bits, n-len(b))...)
+	}
+	return b, nil
+}
+
+// rangeEntriesToIDs returns the vendor IDs covered by the range entries, in ascending order and without duplicates
+//
+// note: each ID is produced once, even when the entries overlap
+func rangeEntriesToIDs(entries []RangeEntry) []int {
+	return slices.AppendSeq(make([]int, 0, len(entries)), rangeEntriesSeq(entries))
+}
+
+// idsToRangeEntries returns the smallest list of range entries covering the given IDs
+func idsToRangeEntries(ids []int) ([]RangeEntry, error) {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	entries := make([]RangeEntry, 0)
+	for _, id := range sorted {
+		if err := checkID(id); err != nil {
+			return nil, err
+		}
+		if n := len(entries); n > 0 && entries[n-1].EndVendorID == id-1 {
+			entries[n-1].EndVendorID = id
+			continue
+		}
+		entries = append(entries, RangeEntry{StartOrOnlyVendorId: id, EndVendorID: id})
+	}
+	return entries, nil
+}
+
+// checkID returns an error if the ID can't be encoded
+func checkID(id int) error {
+	if id < 1 || id > maxID {
+		return fmt.Errorf("invalid id %d", id)
+	}
+	return nil
+}
+
+// ids returns the vendor IDs of the section, in ascending order
+func (s *VendorSection) ids() []int {
+	if s.IsRangeEncoding {
+		return rangeEntriesToIDs(s.RangeEntries)
+	}
+	return s.BitField.ToIDs()
+}
+
+// idsToVendorSection returns a bitfield encoded vendor section with the given vendor IDs
+func idsToVendorSection(ids []int) (VendorSection, error) {
+	b, err := idsToBits(ids, 0)
+	if err != nil {
+		return VendorSection{}, err
+	}
+	return VendorSection{MaxVendorID: slices.Max(append([]int{0}, ids...)), BitField: b}, nil
+}
@@ -0,0 +1,114 @@
+package iabtcf
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// test consent string fields, see TestConsentJSON for the decoded values
+var (
+	testCoreBits = sprintb(2, 6) + // version
+		sprintb(15_000_000_000, 36) + // created
+		sprintb(15_000_000_000, 36) + // last updated
+		sprintb(92, 12) + // cmp id
+		sprintb(1, 12) + // cmp version
+		sprintb(0, 6) + // consent screen
+		sprintb(4, 6) + sprintb(13, 6) + // consent language EN
+		sprintb(34, 12) + // vendor list version
+		sprintb(2, 6) + // tcf policy version
+		"0" + "0" + // is service specific, use non standard stacks
+		"100000000000" + // special features
+		"111000000000000000000000" + // purposes consent
+		"000100000000000000000000" + // purposes li transparency
+		"0" + // purpose one treatment
+		sprintb(0, 6) + sprintb(0, 6) + // publisher cc AA
+		sprintb(5, 16) + "0" + "10001" + // vendor consents bitfield: 1, 5
+		sprintb(30, 16) + "1" + sprintb(1, 12) + "1" + sprintb(10, 16) + sprintb(12, 16) + // vendor li ranges: 10-12
+		sprintb(1, 12) + sprintb(2, 6) + sprintb(1, 2) + sprintb(1, 12) + "0" + sprintb(5, 16) // restriction purpose 2 require consent: 5
+
+	testDisclosedVendorsBits = sprintb(1, 3) + sprintb(5, 16) + "0" + "11001"
+	testPublisherTCBits      = sprintb(3, 3) +
+		"100000000000000000000000" + // pub purposes consent
+		"010000000000000000000000" + // pub purposes li transparency
+		sprintb(2, 6) + "01" + "10" // custom purposes
+)
+
+// encodeBits encodes a string of 1s and 0s into a base64 segment
+func encodeBits(bits string) string {
+	return base64.RawURLEncoding.EncodeToString(sscanb(bits))
+}
+
+func TestConsentJSON(t *testing.T) {
+
+	tests := map[string]struct {
+		consent string
+		want    string
+	}{
+		"core-only": {
+			consent: encodeBits(testCoreBits),
+			want: `{"version":2,"created":"2017-07-14T02:40:00Z","lastUpdated":"2017-07-14T02:40:00Z","cmpId":92,"cmpVersion":1,"consentScreen":0,"consentLanguage":"EN","vendorListVersion":34,"tcfPolicyVersion":2,"isServiceSpecific":false,"useNonStandardStacks":false,` +
+				`"specialFeatureOptIns":[1],"purposesConsent":[1,2,3],"purposesLITransparency":[4],"purposeOneTreatment":false,"publisherCC":"AA",` +
+				`"vendorConsents":[1,5],"vendorLegitimateInterests":[10,11,12],"publisherRestrictions":[{"purposeId":2,"restrictionType":1,"vendors":[5]}]}`,
+		},
+		"with-segments": {
+			consent: encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(testPublisherTCBits),
+			want: `{"version":2,"created":"2017-07-14T02:40:00Z","lastUpdated":"2017-07-14T02:40:00Z","cmpId":92,"cmpVersion":1,"consentScreen":0,"consentLanguage":"EN","vendorListVersion":34,"tcfPolicyVersion":2,"isServiceSpecific":false,"useNonStandardStacks":false,` +
+				`"specialFeatureOptIns":[1],"purposesConsent":[1,2,3],"purposesLITransparency":[4],"purposeOneTreatment":false,"publisherCC":"AA",` +
+				`"vendorConsents":[1,5],"vendorLegitimateInterests":[10,11,12],"publisherRestrictions":[{"purposeId":2,"restrictionType":1,"vendors":[5]}],` +
+				`"disclosedVendors":[1,2,5],` +
+				`"publisherTC":{"purposesConsent":[1],"purposesLITransparency":[2],"numCustomPurposes":2,"customPurposesConsent":[2],"customPurposesLITransparency":[1]}}`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := ParseCoreString(tc.consent)
+			require.NoError(t, err, "unexpected parse error")
+
+			got, err := json.Marshal(parsed)
+			require.NoError(t, err, "unexpected marshal error")
+			require.JSONEq(t, tc.want, string(got))
+
+			// round trip: the schema is stable through unmarshalling
+			var unmarshalled Consent
+			require.NoError(t, json.Unmarshal(got, &unmarshalled), "unexpected unmarshal error")
+			again, err := json.Marshal(&unmarshalled)
+			require.NoError(t, err, "unexpected marshal error")
+			require.JSONEq(t, string(got), string(again))
+
+			for number := 1; number <= 10; number++ {
+				require.Equal(t, parsed.VendorAllowed(number), unmarshalled.VendorAllowed(number), "vendor %d", number)
+				require.Equal(t, parsed.VendorLIAllowed(number), unmarshalled.VendorLIAllowed(number), "vendor li %d", number)
+			}
+			require.Equal(t, parsed.PurposesConsent, unmarshalled.PurposesConsent, "wrong purposes consent")
+		})
+	}
+}
+
+func TestConsentJSONInvalidID(t *testing.T) {
+	var c Consent
+	err := json.Unmarshal([]byte(`{"vendorConsents":[1,0]}`), &c)
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), "vendorConsents: invalid id 0"), err.Error())
+
+	err = json.Unmarshal([]byte(`{"vendorLegitimateInterests":[1,65536]}`), &c)
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), "vendorLegitimateInterests: invalid id 65536"), err.Error())
+}
+
+func TestConsentJSONOverlappingRanges(t *testing.T) {
+	entries := make([]RangeEntry, 0, 1000)
+	for range 1000 {
+		entries = append(entries, RangeEntry{StartOrOnlyVendorId: 1, EndVendorID: 65535})
+	}
+	entries = append(entries, RangeEntry{StartOrOnlyVendorId: 0, EndVendorID: 3})
+
+	ids := rangeEntriesToIDs(entries)
+	require.Len(t, ids, 65535)
+	require.Equal(t, []int{1, 2, 3}, ids[:3])
+	require.Equal(t, []int{}, rangeEntriesToIDs(nil))
+}
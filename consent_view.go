@@ -0,0 +1,28 @@
+package iabtcf
+
+import (
+	"iter"
+)
+
+// ConsentView provides the methods shared by Consent and LazyConsent
+//
+// It allows to check consents without knowing which parser has been used.
+type ConsentView interface {
+	EveryPurposeAllowed(numbers []int) bool
+	PurposeAllowed(number int) bool
+	PurposeLITransparencyAllowed(number int) bool
+	EverySpecialFeatureAllowed(numbers []int) bool
+	SpecialFeatureAllowed(number int) bool
+	VendorAllowed(number int) bool
+	VendorsAllowed(ids []int) Bits
+	FilterAllowed(ids []int) []int
+	ConsentedVendorIDs() iter.Seq[int]
+	LIVendors() iter.Seq[int]
+	DisclosedVendors() iter.Seq[int]
+	AllowedPurposes() iter.Seq[int]
+}
+
+var (
+	_ ConsentView = (*Consent)(nil)
+	_ ConsentView = (*LazyConsent)(nil)
+)
@@ -0,0 +1,182 @@
+// Package consentcache provides a bounded cache of parsed consent strings
+//
+// The same consent string is received many times from one user session, so caching the parsed result
+// avoids decoding it again. The cache is an LRU with a size limit and a TTL, split into shards
+// to reduce lock contention. Parse errors are cached too, so invalid strings are not parsed again.
+//
+// note: results are shared between callers, so they are returned as iabtcf.ImmutableConsent.
+package consentcache
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/travelaudience/go-iabtcf"
+)
+
+const (
+	DefaultSize   = 10_000
+	DefaultTTL    = 10 * time.Minute
+	DefaultShards = 16
+)
+
+// Config defines the limits of the cache
+//
+// note: zero values fall back to the default ones
+type Config struct {
+	// Size is the maximum number of cached strings, over all shards
+	Size int
+	// TTL is the duration a parsed string is kept
+	TTL time.Duration
+	// Shards is the number of independent LRUs, each one having its own lock
+	Shards int
+}
+
+// Stats are the counters of the cache since its creation
+type Stats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+	// Size is the current number of cached strings
+	Size int
+}
+
+// Cache is a concurrency-safe cache of parsed consent strings
+type Cache struct {
+	shards []*shard
+	seed   maphash.Seed
+	ttl    time.Duration
+	now    func() time.Time
+
+	hits, misses, evictions, expirations atomic.Uint64
+}
+
+// New returns a new Cache
+func New(cfg Config) *Cache {
+	size := orDefault(cfg.Size, DefaultSize)
+	nbShards := min(orDefault(cfg.Shards, DefaultShards), size)
+	c := &Cache{
+		shards: make([]*shard, nbShards),
+		seed:   maphash.MakeSeed(),
+		ttl:    cfg.TTL,
+		now:    time.Now,
+	}
+	if c.ttl <= 0 {
+		c.ttl = DefaultTTL
+	}
+	for i := range c.shards {
+		// note: the size is spread over the shards, the first ones taking the remainder
+		capacity := size / nbShards
+		if i < size%nbShards {
+			capacity++
+		}
+		c.shards[i] = &shard{capacity: capacity, items: make(map[key]*list.Element), lru: list.New()}
+	}
+	return c
+}
+
+// Parse returns the result of iabtcf.ParseCoreString as an immutable view, from the cache if possible
+func (c *Cache) Parse(s string) (*iabtcf.ImmutableConsent, error) {
+	return c.get(key{lazy: false, s: s}, func() (*iabtcf.ImmutableConsent, error) {
+		return iabtcf.ParseImmutable(s)
+	})
+}
+
+// LazyParse returns the result of iabtcf.LazyParseCoreString as an immutable view, from the cache if possible
+func (c *Cache) LazyParse(s string) (*iabtcf.ImmutableConsent, error) {
+	return c.get(key{lazy: true, s: s}, func() (*iabtcf.ImmutableConsent, error) {
+		return iabtcf.LazyParseImmutable(s)
+	})
+}
+
+// Stats returns the counters of the cache
+func (c *Cache) Stats() Stats {
+	st := Stats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+	}
+	for _, sh := range c.shards {
+		sh.mu.Lock()
+		st.Size += sh.lru.Len()
+		sh.mu.Unlock()
+	}
+	return st
+}
+
+// get returns the cached result of the key, or parses it and caches the result
+//
+// note: the string is parsed without holding the lock, so concurrent misses on the same key may parse it several times
+func (c *Cache) get(k key, parse func() (*iabtcf.ImmutableConsent, error)) (*iabtcf.ImmutableConsent, error) {
+	sh := c.shards[maphash.String(c.seed, k.s)%uint64(len(c.shards))]
+	now := c.now()
+
+	sh.mu.Lock()
+	if elem, ok := sh.items[k]; ok {
+		e := elem.Value.(*entry)
+		if now.Before(e.expires) {
+			sh.lru.MoveToFront(elem)
+			sh.mu.Unlock()
+			c.hits.Add(1)
+			return e.value, e.err
+		}
+		sh.remove(elem)
+		c.expirations.Add(1)
+	}
+	sh.mu.Unlock()
+	c.misses.Add(1)
+
+	value, err := parse()
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if elem, ok := sh.items[k]; ok {
+		// note: another caller has cached it meanwhile
+		sh.remove(elem)
+	}
+	sh.items[k] = sh.lru.PushFront(&entry{key: k, value: value, err: err, expires: now.Add(c.ttl)})
+	for sh.lru.Len() > sh.capacity {
+		sh.remove(sh.lru.Back())
+		c.evictions.Add(1)
+	}
+	return value, err
+}
+
+// key identifies a cached string, eager and lazy results being cached separately
+type key struct {
+	lazy bool
+	s    string
+}
+
+type entry struct {
+	key     key
+	value   *iabtcf.ImmutableConsent
+	err     error
+	expires time.Time
+}
+
+// shard is an LRU protected by its own lock
+type shard struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[key]*list.Element
+	lru      *list.List
+}
+
+// remove removes the element, the lock must be held
+func (sh *shard) remove(elem *list.Element) {
+	sh.lru.Remove(elem)
+	delete(sh.items, elem.Value.(*entry).key)
+}
+
+func orDefault(value, defaultValue int) int {
+	if value <= 0 {
+		return defaultValue
+	}
+	return value
+}
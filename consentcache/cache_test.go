@@ -0,0 +1,122 @@
+package consentcache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testConsent = "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA"
+
+func TestCache(t *testing.T) {
+
+	c := New(Config{})
+
+	first, err := c.Parse(testConsent)
+	require.NoError(t, err, "unexpected error")
+	second, err := c.Parse(testConsent)
+	require.NoError(t, err, "unexpected error")
+	require.Same(t, first, second, "cached result must be shared")
+	require.True(t, second.VendorAllowed(423))
+
+	lazy, err := c.LazyParse(testConsent)
+	require.NoError(t, err, "unexpected error")
+	require.True(t, lazy.VendorAllowed(423))
+
+	// note: errors are cached too
+	_, err = c.Parse("A")
+	require.EqualError(t, err, "decode failed: illegal base64 data at input byte 0")
+	_, err = c.Parse("A")
+	require.EqualError(t, err, "decode failed: illegal base64 data at input byte 0")
+
+	require.Equal(t, Stats{Hits: 2, Misses: 3, Size: 3}, c.Stats())
+}
+
+func TestCacheEviction(t *testing.T) {
+
+	c := New(Config{Size: 2, Shards: 1})
+
+	_, _ = c.Parse("A")
+	_, _ = c.Parse("B")
+	_, _ = c.Parse("A") // A is now the most recently used
+	_, _ = c.Parse("C") // evicts B
+	_, _ = c.Parse("A")
+	_, _ = c.Parse("B")
+
+	require.Equal(t, Stats{Hits: 2, Misses: 4, Evictions: 2, Size: 2}, c.Stats())
+}
+
+func TestCacheTTL(t *testing.T) {
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New(Config{TTL: time.Minute})
+	c.now = func() time.Time { return now }
+
+	first, err := c.LazyParse(testConsent)
+	require.NoError(t, err, "unexpected error")
+
+	now = now.Add(59 * time.Second)
+	second, err := c.LazyParse(testConsent)
+	require.NoError(t, err, "unexpected error")
+	require.Same(t, first, second)
+
+	now = now.Add(time.Second)
+	third, err := c.LazyParse(testConsent)
+	require.NoError(t, err, "unexpected error")
+	require.NotSame(t, first, third, "expired result must be parsed again")
+
+	require.Equal(t, Stats{Hits: 1, Misses: 2, Expirations: 1, Size: 1}, c.Stats())
+}
+
+func TestCacheSharding(t *testing.T) {
+
+	c := New(Config{Size: 10, Shards: 4})
+	require.Len(t, c.shards, 4)
+	total := 0
+	for _, sh := range c.shards {
+		total += sh.capacity
+	}
+	require.Equal(t, 10, total, "the size must be spread over the shards")
+
+	require.Len(t, New(Config{Size: 3, Shards: 16}).shards, 3, "there can't be more shards than entries")
+}
+
+func TestCacheConcurrency(t *testing.T) {
+
+	c := New(Config{Size: 64, Shards: 8})
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 1000 {
+				s := testConsent
+				if j%10 == 0 {
+					s = fmt.Sprintf("invalid-%d-%d", i, j%100)
+				}
+				if _, err := c.LazyParse(s); s == testConsent && err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	st := c.Stats()
+	require.Equal(t, uint64(16*1000), st.Hits+st.Misses)
+	require.LessOrEqual(t, st.Size, 64)
+}
+
+func BenchmarkCache(b *testing.B) {
+
+	c := New(Config{})
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = c.LazyParse(testConsent)
+		}
+	})
+}
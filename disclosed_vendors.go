@@ -41,6 +41,10 @@ func (c *LazyConsent) IsVendorDisclosed(vendorID int) bool {
 			numEntries := block.ReadIntField(20, 12)
 			offset := 32
 			for range numEntries {
+				if offset >= block.Length() {
+					// note: the next entries are out of bound, so they are empty
+					break
+				}
 				isaRange := block.ReadBoolField(offset)
 				offset++
 				startID := block.ReadIntField(offset, 16)
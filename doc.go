@@ -10,4 +10,22 @@
 // The parsing is done only when the field is accessed.
 // The lazy parser is not optimized for checking multiple vendors.
 // Another drawback of the lazy parser is that the client will have to handle the errors when accessing the fields.
+//
+// The guidance is backed by the Corpus benchmarks ( go test -run '^$' -bench Corpus -benchmem ),
+// each operation including the parse of the string:
+//
+//	                       parse    1 vendor   20 vendors   all fields
+//	short bitfield  eager  1.0µs    0.8µs      1.1µs        1.3µs
+//	                lazy   0.2µs    0.2µs      0.5µs        2.3µs
+//	4000 vendors    eager  36µs     36µs       44µs         59µs
+//	( 1000 ranges ) lazy   6.4µs    29µs       40µs         57µs
+//
+// The disclosed vendors and publisher TC segments add 20% to 100% to every figure, for both parsers.
+// On short strings, the lazy parser is faster unless most fields are read.
+// On large range encoded strings, the first vendor check of the lazy parser builds an index of the range entries,
+// so most of the time saved by the lazy parse is spent by the first vendor check,
+// and the later checks are as fast as the normal parser ones.
+//
+// Both Consent and LazyConsent expose their storage, so they must not be shared between goroutines.
+// Use Consent.Immutable or LazyConsent.Immutable to get an ImmutableConsent, a read-only view safe for concurrent use.
 package iabtcf
@@ -0,0 +1,101 @@
+package iabtcf
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// EncodeCoreString encodes a Consent object into a consent string
+//
+// note: the core string is followed by the disclosed vendors, allowed vendors and publisher TC segments when present.
+// The vendor sections are encoded as described by their IsRangeEncoding field,
+// and the number of range entries is always the length of the RangeEntries slice.
+func EncodeCoreString(p *Consent) (string, error) {
+	if p == nil {
+		return "", fmt.Errorf("consent is nil")
+	}
+
+	w := NewWriter()
+	var err error
+	if err = w.WriteInt(p.Version, 6); err != nil {
+		return "", fmt.Errorf("version encode failed: %w", err)
+	}
+	if err = w.WriteTime(p.Created); err != nil {
+		return "", fmt.Errorf("created encode failed: %w", err)
+	}
+	if err = w.WriteTime(p.LastUpdated); err != nil {
+		return "", fmt.Errorf("last updated encode failed: %w", err)
+	}
+	if err = w.WriteInt(p.CMPID, 12); err != nil {
+		return "", fmt.Errorf("cmp id encode failed: %w", err)
+	}
+	if err = w.WriteInt(p.CMPVersion, 12); err != nil {
+		return "", fmt.Errorf("cmp version encode failed: %w", err)
+	}
+	if err = w.WriteInt(p.ConsentScreen, 6); err != nil {
+		return "", fmt.Errorf("consent screen encode failed: %w", err)
+	}
+	if err = w.WriteString(p.ConsentLanguage, 12); err != nil {
+		return "", fmt.Errorf("consent language encode failed: %w", err)
+	}
+	if err = w.WriteInt(p.VendorListVersion, 12); err != nil {
+		return "", fmt.Errorf("vendor list version encode failed: %w", err)
+	}
+	if err = w.WriteInt(p.TcfPolicyVersion, 6); err != nil {
+		return "", fmt.Errorf("tcf policy version encode failed: %w", err)
+	}
+	w.WriteBool(p.IsServiceSpecific)
+	w.WriteBool(p.UseNonStandardStacks)
+	w.WriteBitField(p.SpecialFeatureOptIns, 12)
+	w.WriteBitField(p.PurposesConsent, 24)
+	w.WriteBitField(p.PurposesLITransparency, 24)
+	w.WriteBool(p.PurposeOneTreatment)
+	if err = w.WriteString(p.PublisherCC, 12); err != nil {
+		return "", fmt.Errorf("publisher country code encode failed: %w", err)
+	}
+	err = w.WriteVendorSection(VendorSection{
+		MaxVendorID:     p.MaxVendorID,
+		IsRangeEncoding: p.IsRangeEncoding,
+		BitField:        p.ConsentedVendors,
+		RangeEntries:    p.RangeEntries,
+	})
+	if err != nil {
+		return "", fmt.Errorf("consented vendors encode failed: %w", err)
+	}
+	if err = w.WriteVendorSection(p.VendorLegitimateInterests); err != nil {
+		return "", fmt.Errorf("vendor legitimate interests encode failed: %w", err)
+	}
+	if err = w.WritePublisherRestrictions(p.PublisherRestrictions); err != nil {
+		return "", fmt.Errorf("publisher restrictions encode failed: %w", err)
+	}
+
+	segments := []string{base64.RawURLEncoding.EncodeToString(w.Bytes())}
+
+	if p.DisclosedVendorsSegment != nil {
+		w = NewWriter()
+		_ = w.WriteInt(disclosedVendorsSegmentType, 3)
+		if err = w.WriteVendorSection(*p.DisclosedVendorsSegment); err != nil {
+			return "", fmt.Errorf("disclosed vendors encode failed: %w", err)
+		}
+		segments = append(segments, base64.RawURLEncoding.EncodeToString(w.Bytes()))
+	}
+	if p.AllowedVendorsSegment != nil {
+		w = NewWriter()
+		_ = w.WriteInt(allowedVendorsSegmentType, 3)
+		if err = w.WriteVendorSection(*p.AllowedVendorsSegment); err != nil {
+			return "", fmt.Errorf("allowed vendors encode failed: %w", err)
+		}
+		segments = append(segments, base64.RawURLEncoding.EncodeToString(w.Bytes()))
+	}
+	if p.PublisherTC != nil {
+		w = NewWriter()
+		_ = w.WriteInt(publisherTCSegmentType, 3)
+		if err = w.WritePublisherTC(p.PublisherTC); err != nil {
+			return "", fmt.Errorf("publisher tc encode failed: %w", err)
+		}
+		segments = append(segments, base64.RawURLEncoding.EncodeToString(w.Bytes()))
+	}
+
+	return strings.Join(segments, "."), nil
+}
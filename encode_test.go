@@ -0,0 +1,63 @@
+package iabtcf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeCoreString(t *testing.T) {
+
+	testCases := map[string]string{
+		"core-only":      encodeBits(testCoreBits),
+		"with-segments":  encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(testPublisherTCBits),
+		"range-encoding": "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA.IF5EX2S5OI2tho2YdF7BEYYwfJxyigMgShgQIsS8NwIeFbBoGPmAAHBG4JAQAGBAkkACBAQIsHGBcCQABgIgRiRCMQEGMjzNKBJBAggkbI0FACCVmnkHS3ZCY70-6u__bA",
+		"v2-big":         "CP9Qr_AP9Qr_AAfETDFRAwEsAP_gAEPgAAigg1NX_H__bX9v-Xr36ft0eY1f99j77uQxBhfJs-4FzLvW_JwX32EzNE36tqYKmRIEu3bBIQFtHJnUTVihaogVrzHsYkGchTNKJ-BkiHMRe2dYCF5vmYtj-QKZ5_p_d3f52T_9_dv-3dzzz91nv3f9f-f1eLida59tH_v_bRKb-_If9_7-_4v0_t_rk2_eTVv_9evv79-u_t____9_9____4AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAAAEQamr_j__tr-3_L179P26PMav--x993IYgwvk2fcC5l3rfk4L77CZmib9W1MFTIkCXbtgkIC2jkzqJqxQtUQK15j2MSDOQpmlE_AyRDmIvbOsBC83zMWx_IFM8_0_u7v87J_-_u3_bu555-6z37v-v_P6vFxOtc-2j_3_tolN_fkP-_9_f8X6f2_1ybfvJq3_-vX39-_Xf2____-_-____8AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAIAAACAA",
+	}
+
+	for name, consent := range testCases {
+		t.Run(name, func(t *testing.T) {
+			want, err := ParseCoreString(consent)
+			require.NoError(t, err, "unexpected parse error")
+
+			encoded, err := EncodeCoreString(want)
+			require.NoError(t, err, "unexpected encode error")
+
+			got, err := ParseCoreString(encoded)
+			require.NoError(t, err, "unexpected parse error of the encoded string")
+			require.Equal(t, want, got, "wrong consent after encoding")
+		})
+	}
+}
+
+func TestEncodeCoreStringErrors(t *testing.T) {
+
+	testCases := map[string]struct {
+		consent *Consent
+		wantErr string
+	}{
+		"nil": {
+			consent: nil,
+			wantErr: "consent is nil",
+		},
+		"version-overflow": {
+			consent: &Consent{Version: 64},
+			wantErr: "version encode failed: value 64 overflows 6 bits",
+		},
+		"invalid-language": {
+			consent: &Consent{Version: 2, ConsentLanguage: "en"},
+			wantErr: `consent language encode failed: string "en" contains a character which is not an uppercase letter`,
+		},
+		"vendor-overflow": {
+			consent: &Consent{Version: 2, IsRangeEncoding: true, RangeEntries: []RangeEntry{{StartOrOnlyVendorId: 1 << 16, EndVendorID: 1 << 16}}},
+			wantErr: "consented vendors encode failed: WriteRangeEntries failed: WriteInt failed: value 65536 overflows 16 bits",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := EncodeCoreString(tc.consent)
+			require.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
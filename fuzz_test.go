@@ -0,0 +1,165 @@
+package iabtcf
+
+import (
+	"iter"
+	"slices"
+	"testing"
+	"time"
+)
+
+// //////////////////////////////////////////////////
+// fuzz targets
+//
+// note: run them with: go test -run '^$' -fuzz FuzzLazyParseCoreString
+// The crashers found are checked into testdata/fuzz, so they are replayed by go test.
+
+// maxVendorIDs is the highest vendor ID which can be encoded, the vendor ID fields having 16 bits
+const maxVendorIDs = 1<<16 - 1
+
+func addFuzzSeeds(f *testing.F) {
+	f.Add("")
+	f.Add("COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA")
+	f.Add("COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA.IF5EX2S5OI2tho2YdF7BEYYwfJxyigMgShgQIsS8NwIeFbBoGPmAAHBG4JAQAGBAkkACBAQIsHGBcCQABgIgRiRCMQEGMjzNKBJBAggkbI0FACCVmnkHS3ZCY70-6u__bA")
+	f.Add(encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(testPublisherTCBits))
+	f.Add(rangeEncodedConsent(f, 20))
+	for _, tc := range benchmarkCorpus(f) {
+		f.Add(tc.s)
+	}
+}
+
+func FuzzParseCoreString(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(fuzzParseCoreString)
+}
+
+func fuzzParseCoreString(t *testing.T, s string) {
+	p, err := ParseCoreString(s)
+	if err != nil {
+		return
+	}
+
+	// note: the range entries are kept as encoded
+	if p.IsRangeEncoding && p.NumEntries != len(p.RangeEntries) {
+		t.Fatalf("%d range entries read, %d encoded", len(p.RangeEntries), p.NumEntries)
+	}
+	for _, seq := range []iter.Seq[int]{p.ConsentedVendorIDs(), p.LIVendors(), p.DisclosedVendors(), p.AllowedPurposes()} {
+		checkIDs(t, slices.Collect(seq))
+	}
+
+	// note: the iterator and the lookups agree, whether the range entries are normalized or not
+	consented := slices.Collect(p.ConsentedVendorIDs())
+	for _, id := range consented[:min(len(consented), 100)] {
+		if !p.VendorAllowed(id) {
+			t.Fatalf("vendor %d is yielded by ConsentedVendorIDs but not allowed", id)
+		}
+	}
+	if got := p.FilterAllowed(consented); !slices.Equal(got, consented) {
+		t.Fatalf("FilterAllowed returned %v, want %v", got, consented)
+	}
+	_ = p.Immutable()
+}
+
+func FuzzLazyParseCoreString(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(fuzzLazyParseCoreString)
+}
+
+func fuzzLazyParseCoreString(t *testing.T, s string) {
+	c, err := LazyParseCoreString(s)
+	if err != nil {
+		if _, eagerErr := ParseCoreString(s); eagerErr == nil {
+			t.Fatalf("only the lazy parser fails: %v", err)
+		}
+		return
+	}
+
+	_ = c.LastUpdated().Add(c.Created().Sub(time.Time{}))
+	_ = c.ConsentLanguage() + c.PublisherCC()
+	_ = c.Version() + c.CMPID() + c.CMPVersion() + c.ConsentScreen() + c.VendorListVersion() + c.TcfPolicyVersion()
+	_ = c.IsServiceSpecific() || c.UseNonStandardStacks() || c.PurposeOneTreatment()
+	_ = c.EveryPurposeAllowed([]int{1, 2, 24, 25}) || c.EveryPurposeLITransparencyAllowed([]int{2, 7}) || c.EverySpecialFeatureAllowed([]int{1, 12})
+
+	consented := slices.Collect(c.ConsentedVendorIDs())
+	checkIDs(t, consented)
+	checkIDs(t, slices.Collect(c.LIVendors()))
+	checkIDs(t, slices.Collect(c.DisclosedVendors()))
+	checkIDs(t, slices.Collect(c.AllowedPurposes()))
+
+	// note: the work done by a range lookup is bounded by the size of the input, not by the encoded number of entries
+	if c.IsRangeEncoding() {
+		if n := len(c.Core.readRangeEntries(NumRangeEntriesField.Offset)); n > c.Core.Length()/17+1 {
+			t.Fatalf("%d range entries read from %d bits", n, c.Core.Length())
+		}
+	}
+
+	// note: the iterator and the lookups agree
+	for _, id := range consented[:min(len(consented), 100)] {
+		if !c.VendorAllowed(id) {
+			t.Fatalf("vendor %d is yielded by ConsentedVendorIDs but not allowed", id)
+		}
+	}
+	if got := c.FilterAllowed(consented); !slices.Equal(got, consented) {
+		t.Fatalf("FilterAllowed returned %v, want %v", got, consented)
+	}
+
+	// note: both parsers must agree, the only string accepted by the lazy parser alone being one truncated
+	// inside the vendor consents, which are then read as not allowed
+	if _, err := ParseCoreString(s); err != nil {
+		if c.Core.vendorSectionEnd(MaxVendorIDField.Offset) <= c.Core.Length() {
+			t.Fatalf("only the eager parser fails: %v", err)
+		}
+		return
+	}
+	if m := CompareParsers(s); m != nil {
+		t.Fatalf("parsers disagree: %v", m)
+	}
+}
+
+func FuzzDisclosedVendors(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Add("COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(sprintb(1, 3)+sprintb(40, 16)+"1"+sprintb(2, 12)+"0"+sprintb(3, 16)+"1"+sprintb(30, 16)+sprintb(40, 16)))
+	f.Fuzz(fuzzDisclosedVendors)
+}
+
+func fuzzDisclosedVendors(t *testing.T, s string) {
+	c, err := LazyParseCoreString(s)
+	if err != nil {
+		return
+	}
+
+	disclosed := slices.Collect(c.DisclosedVendors())
+	checkIDs(t, disclosed)
+	if len(disclosed) > 0 && !c.HasDisclosedVendorsBlock() {
+		t.Fatal("vendors are disclosed without disclosed vendors block")
+	}
+	for _, id := range disclosed[:min(len(disclosed), 100)] {
+		if !c.IsVendorDisclosed(id) {
+			t.Fatalf("vendor %d is yielded by DisclosedVendors but not disclosed", id)
+		}
+	}
+
+	// note: both agree on every vendor, including the range entries above the max vendor ID which are not disclosed
+	for id := 1; id <= 1000; id++ {
+		if _, found := slices.BinarySearch(disclosed, id); found != c.IsVendorDisclosed(id) {
+			t.Fatalf("vendor %d: yielded by DisclosedVendors %t, disclosed %t", id, found, !found)
+		}
+	}
+	for _, id := range []int{-1, 0, maxVendorIDs + 1} {
+		if c.IsVendorDisclosed(id) {
+			t.Fatalf("vendor %d must not be disclosed", id)
+		}
+	}
+}
+
+// checkIDs checks the IDs are valid, sorted and unique
+func checkIDs(t *testing.T, ids []int) {
+	t.Helper()
+	if len(ids) > maxVendorIDs {
+		t.Fatalf("%d IDs yielded", len(ids))
+	}
+	for i, id := range ids {
+		if id < 1 || id > maxVendorIDs || (i > 0 && id <= ids[i-1]) {
+			t.Fatalf("invalid ID %d at index %d", id, i)
+		}
+	}
+}
@@ -2,14 +2,10 @@ module github.com/travelaudience/go-iabtcf
 
 go 1.26
 
-require (
-	github.com/rupertchen/go-bits v0.2.0
-	github.com/stretchr/testify v1.9.0
-)
+require github.com/stretchr/testify v1.9.0
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
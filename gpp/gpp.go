@@ -0,0 +1,159 @@
+// Package gpp implements a decoder of the IAB Global Privacy Platform (GPP) string
+//
+// A GPP string is made of a header followed by the sections listed in the header, separated by '~'.
+// The TCF EU v2 section is handed to the iabtcf parsers, the TCF Canada section to the tcfca parser,
+// the US Privacy section to the usprivacy parser, the other sections are exposed as raw values.
+//
+// It's implemented according to specification: https://github.com/InteractiveAdvertisingBureau/Global-Privacy-Platform/blob/main/Core/Consent%20String%20Specification.md
+package gpp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/travelaudience/go-iabtcf"
+	"github.com/travelaudience/go-iabtcf/tcfca"
+	"github.com/travelaudience/go-iabtcf/usprivacy"
+)
+
+// section IDs, see https://github.com/InteractiveAdvertisingBureau/Global-Privacy-Platform/blob/main/Sections/Section%20Information.md
+const (
+	SectionTCFEUv2 = 2
+	SectionTCFCAv1 = 5
+	SectionUSPv1   = 6
+)
+
+const (
+	headerType = 3
+)
+
+// GPP represents a decoded GPP string
+type GPP struct {
+	Version  int
+	Sections []Section
+}
+
+// Section is a section of a GPP string
+type Section struct {
+	ID int
+	// Value is the section as found in the GPP string
+	Value string
+}
+
+// Segments decodes the base64 segments ( separated by '.' ) of the section
+//
+// note: some sections, like US Privacy, are not base64 encoded and must be read from Value
+func (s Section) Segments() ([][]byte, error) {
+	var res [][]byte
+	for _, segment := range strings.Split(s.Value, ".") {
+		b, err := base64.RawURLEncoding.DecodeString(segment)
+		if err != nil {
+			return nil, fmt.Errorf("section %d decode failed: %w", s.ID, err)
+		}
+		res = append(res, b)
+	}
+	return res, nil
+}
+
+// Parse parses a GPP string
+//
+// note: only the header is decoded, sections are decoded on demand.
+func Parse(s string) (*GPP, error) {
+	if s == "" {
+		return nil, fmt.Errorf("gpp string is empty")
+	}
+	parts := strings.Split(s, "~")
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("header decode failed: %w", err)
+	}
+
+	r := iabtcf.NewReader(b)
+	t, err := r.ReadInt(6)
+	if err != nil {
+		return nil, fmt.Errorf("header type parse failed: %w", err)
+	}
+	if t != headerType {
+		return nil, fmt.Errorf("invalid header type %d", t)
+	}
+	g := &GPP{}
+	g.Version, err = r.ReadInt(6)
+	if err != nil {
+		return nil, fmt.Errorf("version parse failed: %w", err)
+	}
+	entries, err := r.ReadFibonacciRange()
+	if err != nil {
+		return nil, fmt.Errorf("section ids parse failed: %w", err)
+	}
+
+	for _, e := range entries {
+		for id := e.StartOrOnlyVendorId; id <= e.EndVendorID; id++ {
+			if len(g.Sections)+1 >= len(parts) {
+				return nil, fmt.Errorf("section %d is missing", id)
+			}
+			g.Sections = append(g.Sections, Section{ID: id, Value: parts[len(g.Sections)+1]})
+		}
+	}
+	if len(g.Sections)+1 != len(parts) {
+		return nil, fmt.Errorf("header lists %d sections, found %d", len(g.Sections), len(parts)-1)
+	}
+
+	return g, nil
+}
+
+// SectionIDs returns the IDs of the sections, in the order of the header
+func (g *GPP) SectionIDs() []int {
+	ids := make([]int, 0, len(g.Sections))
+	for _, s := range g.Sections {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+// Section returns the section with the given ID, if present
+func (g *GPP) Section(id int) (Section, bool) {
+	for _, s := range g.Sections {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Section{}, false
+}
+
+// TCFEU parses the TCF EU v2 section with iabtcf.ParseCoreString
+func (g *GPP) TCFEU() (*iabtcf.Consent, error) {
+	s, ok := g.Section(SectionTCFEUv2)
+	if !ok {
+		return nil, fmt.Errorf("tcf eu v2 section is missing")
+	}
+	return iabtcf.ParseCoreString(s.Value)
+}
+
+// LazyTCFEU parses the TCF EU v2 section with iabtcf.LazyParseCoreString
+func (g *GPP) LazyTCFEU() (*iabtcf.LazyConsent, error) {
+	s, ok := g.Section(SectionTCFEUv2)
+	if !ok {
+		return nil, fmt.Errorf("tcf eu v2 section is missing")
+	}
+	return iabtcf.LazyParseCoreString(s.Value)
+}
+
+// TCFCA parses the TCF Canada section with tcfca.Parse
+func (g *GPP) TCFCA() (*tcfca.Consent, error) {
+	s, ok := g.Section(SectionTCFCAv1)
+	if !ok {
+		return nil, fmt.Errorf("tcf ca v1 section is missing")
+	}
+	return tcfca.Parse(s.Value)
+}
+
+// USPrivacy parses the US Privacy section with usprivacy.Parse
+func (g *GPP) USPrivacy() (*usprivacy.USPrivacy, error) {
+	s, ok := g.Section(SectionUSPv1)
+	if !ok {
+		return nil, fmt.Errorf("us privacy v1 section is missing")
+	}
+	return usprivacy.Parse(s.Value)
+}
@@ -0,0 +1,106 @@
+package gpp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testTCString = "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA"
+
+func TestParse(t *testing.T) {
+
+	type TestCase struct {
+		gpp            string
+		wantErr        string
+		wantVersion    int
+		wantSectionIDs []int
+	}
+
+	testCases := map[string]*TestCase{
+		"empty": {
+			gpp:     "",
+			wantErr: "gpp string is empty",
+		},
+		"tcf-eu": {
+			gpp:            "DBABMA~" + testTCString,
+			wantVersion:    1,
+			wantSectionIDs: []int{2},
+		},
+		"tcf-eu-and-usp": {
+			gpp:            "DBACNYA~" + testTCString + "~1YNN",
+			wantVersion:    1,
+			wantSectionIDs: []int{2, 6},
+		},
+		"usp-only": {
+			gpp:            "DBABTA~1YNN",
+			wantVersion:    1,
+			wantSectionIDs: []int{6},
+		},
+		"invalid-header-type": {
+			gpp:     "BBABMA~" + testTCString,
+			wantErr: "invalid header type 1",
+		},
+		"missing-section": {
+			gpp:     "DBACNYA~" + testTCString,
+			wantErr: "section 6 is missing",
+		},
+		"extra-section": {
+			gpp:     "DBABMA~" + testTCString + "~1YNN",
+			wantErr: "header lists 1 sections, found 2",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(tc.gpp)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantVersion, got.Version)
+			require.Equal(t, tc.wantSectionIDs, got.SectionIDs())
+		})
+	}
+}
+
+func TestSections(t *testing.T) {
+
+	g, err := Parse("DBACNYA~" + testTCString + "~1YNN")
+	require.NoError(t, err)
+
+	c, err := g.TCFEU()
+	require.NoError(t, err)
+	require.True(t, c.VendorAllowed(423))
+
+	lc, err := g.LazyTCFEU()
+	require.NoError(t, err)
+	require.True(t, lc.VendorAllowed(423))
+
+	usp, ok := g.Section(SectionUSPv1)
+	require.True(t, ok)
+	require.Equal(t, "1YNN", usp.Value)
+	u, err := g.USPrivacy()
+	require.NoError(t, err)
+	require.False(t, u.OptedOut())
+
+	segments, err := Section{ID: SectionTCFEUv2, Value: testTCString}.Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	_, ok = g.Section(SectionTCFCAv1)
+	require.False(t, ok)
+
+	g, err = Parse("DBABTA~1YNN")
+	require.NoError(t, err)
+	_, err = g.TCFEU()
+	require.EqualError(t, err, "tcf eu v2 section is missing")
+	_, err = g.TCFCA()
+	require.EqualError(t, err, "tcf ca v1 section is missing")
+
+	g, err = Parse("DBABMA~" + testTCString)
+	require.NoError(t, err)
+	_, err = g.USPrivacy()
+	require.EqualError(t, err, "us privacy v1 section is missing")
+}
@@ -0,0 +1,133 @@
+package httpconsent
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/travelaudience/go-iabtcf"
+)
+
+const (
+	// DefaultCookieMaxAge is the maximum age of the consent cookie, the TCF policy requires to renew consent at least every 13 months
+	DefaultCookieMaxAge = 390 * 24 * time.Hour
+
+	// maxCookieValueSize is the maximum size of a cookie value, browsers limit the size of a cookie to 4096 bytes,
+	// name and attributes included
+	maxCookieValueSize = 3800
+	// maxCookieChunks bounds the number of chunks read from a request
+	maxCookieChunks = 16
+)
+
+// CookieOptions defines the attributes of the cookies written by WriteCookie
+//
+// note: zero values fall back to sane defaults: path "/", DefaultCookieMaxAge and SameSite Lax.
+// Cookies are always Secure, and not HttpOnly so a client side CMP can read them.
+type CookieOptions struct {
+	Domain   string
+	Path     string
+	MaxAge   time.Duration
+	SameSite http.SameSite
+}
+
+// ReadCookie reads the value of the cookie from the request
+//
+// If the cookie is absent, the chunked cookie convention used by some CMPs for long strings is tried,
+// the value being split into cookies named <name>_0, <name>_1, ...
+func ReadCookie(r *http.Request, name string) (string, bool) {
+	if cookie, err := r.Cookie(name); err == nil && cookie.Value != "" {
+		return cookie.Value, true
+	}
+
+	value := ""
+	for i := range maxCookieChunks {
+		cookie, err := r.Cookie(chunkName(name, i))
+		if err != nil {
+			break
+		}
+		value += cookie.Value
+	}
+	return value, value != ""
+}
+
+// WriteCookie writes the value as a cookie, split into chunks when too long for a single cookie
+//
+// note: if r is not nil, the cookies of the request which are not rewritten ( the unchunked cookie or previous chunks )
+// are expired, so ReadCookie doesn't read a stale value
+//
+// note: an error is returned, and no cookie is written, when more chunks than ReadCookie reads would be needed
+func WriteCookie(w http.ResponseWriter, r *http.Request, name, value string, opts CookieOptions) error {
+	if n := (len(value) + maxCookieValueSize - 1) / maxCookieValueSize; n > maxCookieChunks {
+		return fmt.Errorf("cookie value too long: %d chunks needed, at most %d", n, maxCookieChunks)
+	}
+
+	var chunks []string
+	for len(value) > maxCookieValueSize {
+		chunks = append(chunks, value[:maxCookieValueSize])
+		value = value[maxCookieValueSize:]
+	}
+	if chunks == nil {
+		http.SetCookie(w, newCookie(name, value, opts))
+	} else {
+		chunks = append(chunks, value)
+		for i, chunk := range chunks {
+			http.SetCookie(w, newCookie(chunkName(name, i), chunk, opts))
+		}
+	}
+
+	if r == nil {
+		return nil
+	}
+	if _, err := r.Cookie(name); err == nil && chunks != nil {
+		http.SetCookie(w, expiredCookie(name, opts))
+	}
+	for i := len(chunks); i < maxCookieChunks; i++ {
+		if _, err := r.Cookie(chunkName(name, i)); err != nil {
+			break
+		}
+		http.SetCookie(w, expiredCookie(chunkName(name, i), opts))
+	}
+	return nil
+}
+
+// WriteConsentCookie encodes the consent and writes it with WriteCookie
+//
+// note: an empty name falls back to DefaultCookieName
+func WriteConsentCookie(w http.ResponseWriter, r *http.Request, name string, consent *iabtcf.Consent, opts CookieOptions) error {
+	value, err := iabtcf.EncodeCoreString(consent)
+	if err != nil {
+		return fmt.Errorf("consent encode failed: %w", err)
+	}
+	return WriteCookie(w, r, orDefault(name, DefaultCookieName), value, opts)
+}
+
+func chunkName(name string, i int) string {
+	return name + "_" + strconv.Itoa(i)
+}
+
+func newCookie(name, value string, opts CookieOptions) *http.Cookie {
+	maxAge := opts.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultCookieMaxAge
+	}
+	sameSite := opts.SameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteLaxMode
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   opts.Domain,
+		Path:     orDefault(opts.Path, "/"),
+		MaxAge:   int(maxAge / time.Second),
+		Secure:   true,
+		SameSite: sameSite,
+	}
+}
+
+func expiredCookie(name string, opts CookieOptions) *http.Cookie {
+	cookie := newCookie(name, "", opts)
+	cookie.MaxAge = -1
+	return cookie
+}
@@ -0,0 +1,172 @@
+package httpconsent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/travelaudience/go-iabtcf"
+)
+
+func TestReadCookie(t *testing.T) {
+
+	type TestCase struct {
+		cookies []*http.Cookie
+		want    string
+		wantOK  bool
+	}
+
+	testCases := map[string]*TestCase{
+		"absent": {},
+		"single": {
+			cookies: []*http.Cookie{{Name: DefaultCookieName, Value: testConsent}},
+			want:    testConsent,
+			wantOK:  true,
+		},
+		"chunked": {
+			cookies: []*http.Cookie{
+				{Name: DefaultCookieName + "_0", Value: testConsent[:10]},
+				{Name: DefaultCookieName + "_1", Value: testConsent[10:20]},
+				{Name: DefaultCookieName + "_2", Value: testConsent[20:]},
+			},
+			want:   testConsent,
+			wantOK: true,
+		},
+		"single-takes-precedence": {
+			cookies: []*http.Cookie{
+				{Name: DefaultCookieName + "_0", Value: "stale"},
+				{Name: DefaultCookieName, Value: testConsent},
+			},
+			want:   testConsent,
+			wantOK: true,
+		},
+		"missing-first-chunk": {
+			cookies: []*http.Cookie{{Name: DefaultCookieName + "_1", Value: testConsent}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, cookie := range tc.cookies {
+				req.AddCookie(cookie)
+			}
+			got, ok := ReadCookie(req, DefaultCookieName)
+			require.Equal(t, tc.wantOK, ok)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestWriteCookie(t *testing.T) {
+
+	long := strings.Repeat("A", 2*maxCookieValueSize+10)
+
+	type TestCase struct {
+		value       string
+		opts        CookieOptions
+		reqCookies  []*http.Cookie
+		wantNames   []string
+		wantExpired []string
+	}
+
+	testCases := map[string]*TestCase{
+		"single": {
+			value:     testConsent,
+			wantNames: []string{DefaultCookieName},
+		},
+		"single-expires-chunks": {
+			value:       testConsent,
+			reqCookies:  []*http.Cookie{{Name: DefaultCookieName + "_0", Value: "a"}, {Name: DefaultCookieName + "_1", Value: "b"}},
+			wantNames:   []string{DefaultCookieName},
+			wantExpired: []string{DefaultCookieName + "_0", DefaultCookieName + "_1"},
+		},
+		"chunked": {
+			value:     long,
+			wantNames: []string{DefaultCookieName + "_0", DefaultCookieName + "_1", DefaultCookieName + "_2"},
+		},
+		"chunked-expires-single-and-extra-chunks": {
+			value: long,
+			reqCookies: []*http.Cookie{
+				{Name: DefaultCookieName, Value: "a"},
+				{Name: DefaultCookieName + "_0", Value: "a"},
+				{Name: DefaultCookieName + "_1", Value: "b"},
+				{Name: DefaultCookieName + "_2", Value: "c"},
+				{Name: DefaultCookieName + "_3", Value: "d"},
+			},
+			wantNames:   []string{DefaultCookieName + "_0", DefaultCookieName + "_1", DefaultCookieName + "_2"},
+			wantExpired: []string{DefaultCookieName, DefaultCookieName + "_3"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, cookie := range tc.reqCookies {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+			require.NoError(t, WriteCookie(rec, req, DefaultCookieName, tc.value, tc.opts))
+
+			var names, expired []string
+			replayed := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, cookie := range rec.Result().Cookies() {
+				if cookie.MaxAge < 0 {
+					expired = append(expired, cookie.Name)
+					continue
+				}
+				names = append(names, cookie.Name)
+				require.Equal(t, "/", cookie.Path)
+				require.Equal(t, int(DefaultCookieMaxAge/time.Second), cookie.MaxAge)
+				require.True(t, cookie.Secure)
+				require.False(t, cookie.HttpOnly)
+				require.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+				replayed.AddCookie(cookie)
+			}
+			require.Equal(t, tc.wantNames, names)
+			require.Equal(t, tc.wantExpired, expired)
+
+			got, ok := ReadCookie(replayed, DefaultCookieName)
+			require.True(t, ok)
+			require.Equal(t, tc.value, got, "written value must be read back")
+		})
+	}
+}
+
+func TestWriteCookieTooLong(t *testing.T) {
+	rec := httptest.NewRecorder()
+	require.NoError(t, WriteCookie(rec, nil, DefaultCookieName, strings.Repeat("A", maxCookieChunks*maxCookieValueSize), CookieOptions{}))
+	require.Len(t, rec.Result().Cookies(), maxCookieChunks)
+
+	rec = httptest.NewRecorder()
+	err := WriteCookie(rec, nil, DefaultCookieName, strings.Repeat("A", maxCookieChunks*maxCookieValueSize+1), CookieOptions{})
+	require.EqualError(t, err, "cookie value too long: 17 chunks needed, at most 16")
+	require.Empty(t, rec.Result().Cookies(), "no cookie must be written")
+}
+
+func TestWriteConsentCookie(t *testing.T) {
+
+	consent, err := iabtcf.ParseCoreString(testConsent)
+	require.NoError(t, err, "unexpected parse error")
+
+	rec := httptest.NewRecorder()
+	opts := CookieOptions{Domain: "example.com", Path: "/cmp", MaxAge: time.Hour, SameSite: http.SameSiteNoneMode}
+	require.NoError(t, WriteConsentCookie(rec, nil, "", consent, opts))
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, DefaultCookieName, cookies[0].Name)
+	require.Equal(t, "example.com", cookies[0].Domain)
+	require.Equal(t, "/cmp", cookies[0].Path)
+	require.Equal(t, 3600, cookies[0].MaxAge)
+	require.Equal(t, http.SameSiteNoneMode, cookies[0].SameSite)
+
+	got, err := iabtcf.ParseCoreString(cookies[0].Value)
+	require.NoError(t, err, "unexpected parse error of the written cookie")
+	require.True(t, got.VendorAllowed(423))
+
+	require.EqualError(t, WriteConsentCookie(rec, nil, "", nil, opts), "consent encode failed: consent is nil")
+}
@@ -0,0 +1,191 @@
+// Package httpconsent provides a net/http middleware extracting and parsing the TC String of each request
+//
+// The TC String is read from the gdpr_consent query parameter, with a fallback on the euconsent-v2 cookie ( see ReadCookie ),
+// and the gdpr query parameter tells if GDPR applies.
+// The US Privacy string is read from the us_privacy query parameter, with a fallback on the usprivacy cookie.
+// The result is stored in the request context and can be retrieved with FromContext.
+// The parsed consent is an iabtcf.ImmutableConsent, so it can be shared with other goroutines,
+// and identical consent strings can be parsed once by setting Config.Cache.
+package httpconsent
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/travelaudience/go-iabtcf"
+	"github.com/travelaudience/go-iabtcf/consentcache"
+	"github.com/travelaudience/go-iabtcf/usprivacy"
+)
+
+const (
+	DefaultGDPRParam    = "gdpr"
+	DefaultConsentParam = "gdpr_consent"
+	DefaultCookieName   = "euconsent-v2"
+
+	DefaultUSPrivacyParam      = "us_privacy"
+	DefaultUSPrivacyCookieName = "usprivacy"
+)
+
+// Mode defines which parser is used
+type Mode int
+
+const (
+	// Lazy uses LazyParseCoreString, see package iabtcf for the trade-offs
+	Lazy Mode = iota
+	// Eager uses ParseCoreString
+	Eager
+)
+
+// Config defines how the consent is extracted and parsed
+//
+// note: empty names fall back to the default ones
+type Config struct {
+	GDPRParam    string
+	ConsentParam string
+	CookieName   string
+	Mode         Mode
+
+	USPrivacyParam      string
+	USPrivacyCookieName string
+
+	// Cache, if set, is used to parse the consent string, the parser still being chosen by Mode
+	Cache *consentcache.Cache
+
+	// OnParseError is called instead of the next handler when the consent string can't be parsed.
+	// If nil, the next handler is called and the error is available in Result.Err.
+	//
+	// note: it's not called for US Privacy errors, which are only available in Result.USPrivacyErr.
+	OnParseError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Result is the consent extracted from a request
+type Result struct {
+	// GDPRApplies is nil when the gdpr parameter is absent or invalid
+	GDPRApplies *bool
+	// Raw is the consent string, empty when absent
+	Raw string
+	// Consent is nil when the consent string is absent or can't be parsed
+	//
+	// note: it may be shared with other requests when Config.Cache is set
+	Consent *iabtcf.ImmutableConsent
+	// Err is the parse error
+	Err error
+
+	// USPrivacyRaw is the US Privacy string, empty when absent
+	USPrivacyRaw string
+	// USPrivacy is nil when the US Privacy string is absent or can't be parsed
+	USPrivacy *usprivacy.USPrivacy
+	// USPrivacyErr is the US Privacy parse error
+	USPrivacyErr error
+}
+
+// MayProcess checks if the vendor may process the personal data of the request for every given purpose
+//
+// GDPR is considered to apply when the gdpr parameter is 1, or when it's absent and a consent string is present.
+// In that case, the consent must allow the vendor and every purpose, so an absent or invalid consent string denies processing.
+// Then, if CCPA applies, processing is denied when user has opted out of sale.
+func (res *Result) MayProcess(vendorID int, purposes ...int) bool {
+	gdprApplies := res.Raw != ""
+	if res.GDPRApplies != nil {
+		gdprApplies = *res.GDPRApplies
+	}
+	if gdprApplies && (res.Consent == nil || !res.Consent.VendorAllowed(vendorID) || !res.Consent.EveryPurposeAllowed(purposes)) {
+		return false
+	}
+	if res.USPrivacy != nil && res.USPrivacy.Applies() && res.USPrivacy.OptedOut() {
+		return false
+	}
+	return true
+}
+
+// RejectParseError responds with 400 Bad Request, it can be used as Config.OnParseError
+func RejectParseError(w http.ResponseWriter, _ *http.Request, _ error) {
+	http.Error(w, "invalid consent string", http.StatusBadRequest)
+}
+
+// Middleware returns a middleware which extracts and parses the consent of each request,
+// and stores the Result in the request context
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res := Extract(r, cfg)
+			if cfg.OnParseError != nil && res.Err != nil {
+				cfg.OnParseError(w, r, res.Err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), res)))
+		})
+	}
+}
+
+// Extract extracts and parses the consent of the request
+func Extract(r *http.Request, cfg Config) *Result {
+	query := r.URL.Query()
+	res := &Result{}
+
+	switch query.Get(orDefault(cfg.GDPRParam, DefaultGDPRParam)) {
+	case "1":
+		applies := true
+		res.GDPRApplies = &applies
+	case "0":
+		applies := false
+		res.GDPRApplies = &applies
+	}
+
+	res.USPrivacyRaw = queryOrCookie(r, query, orDefault(cfg.USPrivacyParam, DefaultUSPrivacyParam), orDefault(cfg.USPrivacyCookieName, DefaultUSPrivacyCookieName))
+	if res.USPrivacyRaw != "" {
+		res.USPrivacy, res.USPrivacyErr = usprivacy.Parse(res.USPrivacyRaw)
+	}
+
+	res.Raw = queryOrCookie(r, query, orDefault(cfg.ConsentParam, DefaultConsentParam), orDefault(cfg.CookieName, DefaultCookieName))
+	if res.Raw == "" {
+		return res
+	}
+
+	res.Consent, res.Err = parse(res.Raw, cfg)
+	return res
+}
+
+// parse parses the consent string with the parser of the mode, through the cache if any
+func parse(s string, cfg Config) (*iabtcf.ImmutableConsent, error) {
+	switch {
+	case cfg.Cache != nil && cfg.Mode == Eager:
+		return cfg.Cache.Parse(s)
+	case cfg.Cache != nil:
+		return cfg.Cache.LazyParse(s)
+	case cfg.Mode == Eager:
+		return iabtcf.ParseImmutable(s)
+	default:
+		return iabtcf.LazyParseImmutable(s)
+	}
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx storing res
+func NewContext(ctx context.Context, res *Result) context.Context {
+	return context.WithValue(ctx, contextKey{}, res)
+}
+
+// FromContext returns the Result stored in ctx, if any
+func FromContext(ctx context.Context) (*Result, bool) {
+	res, ok := ctx.Value(contextKey{}).(*Result)
+	return res, ok
+}
+
+// queryOrCookie returns the value of the query parameter, with a fallback on the cookie ( chunked or not )
+func queryOrCookie(r *http.Request, query url.Values, param, cookieName string) string {
+	if value := query.Get(param); value != "" {
+		return value
+	}
+	value, _ := ReadCookie(r, cookieName)
+	return value
+}
+
+func orDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
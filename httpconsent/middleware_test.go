@@ -0,0 +1,284 @@
+package httpconsent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/travelaudience/go-iabtcf/consentcache"
+)
+
+const testConsent = "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA"
+
+func TestMiddleware(t *testing.T) {
+
+	type TestCase struct {
+		cfg             Config
+		target          string
+		cookie          *http.Cookie
+		wantStatus      int
+		wantGDPRApplies *bool
+		wantRaw         string
+		wantConsent     bool
+		wantLazy        bool
+		wantErr         bool
+	}
+
+	applies, notApplies := true, false
+
+	testCases := map[string]*TestCase{
+		"no-consent": {
+			target:     "/",
+			wantStatus: http.StatusOK,
+		},
+		"query": {
+			target:          "/?gdpr=1&gdpr_consent=" + testConsent,
+			wantStatus:      http.StatusOK,
+			wantGDPRApplies: &applies,
+			wantRaw:         testConsent,
+			wantConsent:     true,
+			wantLazy:        true,
+		},
+		"query-eager": {
+			cfg:             Config{Mode: Eager},
+			target:          "/?gdpr=0&gdpr_consent=" + testConsent,
+			wantStatus:      http.StatusOK,
+			wantGDPRApplies: &notApplies,
+			wantRaw:         testConsent,
+			wantConsent:     true,
+		},
+		"cache": {
+			cfg:         Config{Cache: consentcache.New(consentcache.Config{})},
+			target:      "/?gdpr_consent=" + testConsent,
+			wantStatus:  http.StatusOK,
+			wantRaw:     testConsent,
+			wantConsent: true,
+			wantLazy:    true,
+		},
+		"cache-eager": {
+			cfg:         Config{Mode: Eager, Cache: consentcache.New(consentcache.Config{})},
+			target:      "/?gdpr_consent=" + testConsent,
+			wantStatus:  http.StatusOK,
+			wantRaw:     testConsent,
+			wantConsent: true,
+		},
+		"cookie-fallback": {
+			target:      "/",
+			cookie:      &http.Cookie{Name: DefaultCookieName, Value: testConsent},
+			wantStatus:  http.StatusOK,
+			wantRaw:     testConsent,
+			wantConsent: true,
+			wantLazy:    true,
+		},
+		"custom-names": {
+			cfg:             Config{GDPRParam: "g", ConsentParam: "c", CookieName: "tc"},
+			target:          "/?g=1&gdpr_consent=ignored",
+			cookie:          &http.Cookie{Name: "tc", Value: testConsent},
+			wantStatus:      http.StatusOK,
+			wantGDPRApplies: &applies,
+			wantRaw:         testConsent,
+			wantConsent:     true,
+			wantLazy:        true,
+		},
+		"parse-error": {
+			target:     "/?gdpr_consent=A",
+			wantStatus: http.StatusOK,
+			wantRaw:    "A",
+			wantErr:    true,
+		},
+		"parse-error-rejected": {
+			cfg:        Config{OnParseError: RejectParseError},
+			target:     "/?gdpr_consent=A",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var got *Result
+			handler := Middleware(tc.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var ok bool
+				got, ok = FromContext(r.Context())
+				require.True(t, ok, "missing result")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.wantStatus, rec.Code)
+			if tc.wantStatus != http.StatusOK {
+				require.Nil(t, got, "next handler should not be called")
+				return
+			}
+
+			require.Equal(t, tc.wantGDPRApplies, got.GDPRApplies)
+			require.Equal(t, tc.wantRaw, got.Raw)
+			require.Equal(t, tc.wantErr, got.Err != nil, "unexpected error: %v", got.Err)
+			require.Equal(t, tc.wantConsent, got.Consent != nil)
+			if got.Consent != nil {
+				// note: only the views built from an eager Consent can return it
+				require.Equal(t, tc.wantLazy, got.Consent.Consent() == nil)
+				require.True(t, got.Consent.VendorAllowed(423))
+			}
+		})
+	}
+}
+
+func TestFromContextMissing(t *testing.T) {
+	_, ok := FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+	require.False(t, ok)
+}
+
+func TestMiddlewareUSPrivacy(t *testing.T) {
+
+	type TestCase struct {
+		cfg        Config
+		target     string
+		cookie     *http.Cookie
+		wantStatus int
+		wantRaw    string
+		wantOptOut bool
+		wantErr    bool
+	}
+
+	testCases := map[string]*TestCase{
+		"absent": {
+			target:     "/",
+			wantStatus: http.StatusOK,
+		},
+		"query": {
+			target:     "/?us_privacy=1YYN",
+			wantStatus: http.StatusOK,
+			wantRaw:    "1YYN",
+			wantOptOut: true,
+		},
+		"cookie-fallback": {
+			target:     "/",
+			cookie:     &http.Cookie{Name: DefaultUSPrivacyCookieName, Value: "1YNN"},
+			wantStatus: http.StatusOK,
+			wantRaw:    "1YNN",
+		},
+		"custom-names": {
+			cfg:        Config{USPrivacyParam: "usp", USPrivacyCookieName: "ccpa"},
+			target:     "/?us_privacy=ignored",
+			cookie:     &http.Cookie{Name: "ccpa", Value: "1NYN"},
+			wantStatus: http.StatusOK,
+			wantRaw:    "1NYN",
+			wantOptOut: true,
+		},
+		"parse-error": {
+			target:     "/?us_privacy=1YN",
+			wantStatus: http.StatusOK,
+			wantRaw:    "1YN",
+			wantErr:    true,
+		},
+		"parse-error-not-rejected": {
+			cfg:        Config{OnParseError: RejectParseError},
+			target:     "/?gdpr_consent=" + testConsent + "&us_privacy=1YN",
+			wantStatus: http.StatusOK,
+			wantRaw:    "1YN",
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var got *Result
+			handler := Middleware(tc.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.cookie != nil {
+				req.AddCookie(tc.cookie)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.wantStatus, rec.Code)
+			if tc.wantStatus != http.StatusOK {
+				require.Nil(t, got, "next handler should not be called")
+				return
+			}
+
+			require.Equal(t, tc.wantRaw, got.USPrivacyRaw)
+			require.Equal(t, tc.wantErr, got.USPrivacyErr != nil, "unexpected error: %v", got.USPrivacyErr)
+			require.Equal(t, tc.wantRaw != "" && !tc.wantErr, got.USPrivacy != nil)
+			if got.USPrivacy != nil {
+				require.Equal(t, tc.wantOptOut, got.USPrivacy.OptedOut())
+			}
+		})
+	}
+}
+
+func TestMayProcess(t *testing.T) {
+
+	type TestCase struct {
+		target   string
+		vendorID int
+		purposes []int
+		want     bool
+	}
+
+	testCases := map[string]*TestCase{
+		"no-regulation": {
+			target:   "/",
+			vendorID: 1,
+			want:     true,
+		},
+		"gdpr-allowed": {
+			target:   "/?gdpr=1&gdpr_consent=" + testConsent,
+			vendorID: 423,
+			purposes: []int{1, 2},
+			want:     true,
+		},
+		"gdpr-vendor-not-allowed": {
+			target:   "/?gdpr=1&gdpr_consent=" + testConsent,
+			vendorID: 1,
+		},
+		"gdpr-purpose-not-allowed": {
+			target:   "/?gdpr=1&gdpr_consent=" + testConsent,
+			vendorID: 423,
+			purposes: []int{1, 11},
+		},
+		"gdpr-without-consent": {
+			target:   "/?gdpr=1",
+			vendorID: 423,
+		},
+		"gdpr-invalid-consent": {
+			target:   "/?gdpr_consent=A",
+			vendorID: 423,
+		},
+		"gdpr-not-applies": {
+			target:   "/?gdpr=0&gdpr_consent=" + testConsent,
+			vendorID: 1,
+			want:     true,
+		},
+		"ccpa-opt-out": {
+			target:   "/?gdpr=1&gdpr_consent=" + testConsent + "&us_privacy=1YYN",
+			vendorID: 423,
+		},
+		"ccpa-no-opt-out": {
+			target:   "/?us_privacy=1YNN",
+			vendorID: 1,
+			want:     true,
+		},
+		"ccpa-not-applicable": {
+			target:   "/?us_privacy=1---",
+			vendorID: 1,
+			want:     true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			res := Extract(httptest.NewRequest(http.MethodGet, tc.target, nil), Config{})
+			require.Equal(t, tc.want, res.MayProcess(tc.vendorID, tc.purposes...))
+		})
+	}
+}
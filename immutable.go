@@ -0,0 +1,331 @@
+package iabtcf
+
+import (
+	"iter"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// ImmutableConsent is a read-only view of a parsed consent string
+//
+// Consent and LazyConsent expose their storage ( Bits, RangeEntries, Core ... ) so they can't be safely shared.
+// ImmutableConsent keeps a private copy of it and only provides accessors,
+// so the same value can be shared between goroutines, or through a cache, without synchronization.
+//
+// note: it's built with Consent.Immutable or LazyConsent.Immutable, and keeps the trade-offs of the parser used.
+type ImmutableConsent struct {
+	// note: exactly one of them is set
+	eager *Consent
+	lazy  *LazyConsent
+}
+
+var _ ConsentView = (*ImmutableConsent)(nil)
+
+// Immutable returns a read-only view of a deep copy of the consent
+func (p *Consent) Immutable() *ImmutableConsent {
+	return &ImmutableConsent{eager: p.Clone()}
+}
+
+// Immutable returns a read-only view of a copy of the consent
+func (c *LazyConsent) Immutable() *ImmutableConsent {
+	lazy := &LazyConsent{Core: slices.Clone(c.Core), Extras: make([]Bits, len(c.Extras))}
+	for i, extra := range c.Extras {
+		lazy.Extras[i] = slices.Clone(extra)
+	}
+	return &ImmutableConsent{lazy: lazy}
+}
+
+// ParseImmutable parses the consent string with ParseCoreString, and returns a read-only view of the result
+//
+// note: the parsed consent is referenced by nothing else, so it's not copied, unlike with Consent.Immutable
+func ParseImmutable(s string) (*ImmutableConsent, error) {
+	p, err := ParseCoreString(s)
+	if err != nil {
+		return nil, err
+	}
+	return wrapConsent(p), nil
+}
+
+// LazyParseImmutable parses the consent string with LazyParseCoreString, and returns a read-only view of the result
+//
+// note: the parsed consent is referenced by nothing else, so it's not copied, unlike with LazyConsent.Immutable
+func LazyParseImmutable(s string) (*ImmutableConsent, error) {
+	c, err := LazyParseCoreString(s)
+	if err != nil {
+		return nil, err
+	}
+	return wrapLazyConsent(c), nil
+}
+
+// wrapConsent returns a read-only view of p, which must not be referenced anywhere else
+func wrapConsent(p *Consent) *ImmutableConsent {
+	return &ImmutableConsent{eager: p}
+}
+
+// wrapLazyConsent returns a read-only view of c, which must not be referenced anywhere else
+func wrapLazyConsent(c *LazyConsent) *ImmutableConsent {
+	return &ImmutableConsent{lazy: c}
+}
+
+// Clone returns a deep copy of the consent, sharing no memory with it
+func (p *Consent) Clone() *Consent {
+	clone := *p
+	clone.SpecialFeatureOptIns = slices.Clone(p.SpecialFeatureOptIns)
+	clone.PurposesConsent = slices.Clone(p.PurposesConsent)
+	clone.PurposesLITransparency = slices.Clone(p.PurposesLITransparency)
+	clone.ConsentedVendors = slices.Clone(p.ConsentedVendors)
+	clone.RangeEntries = slices.Clone(p.RangeEntries)
+	clone.normalized = p.normalized.clone(p.RangeEntries, clone.RangeEntries)
+	clone.VendorLegitimateInterests = *p.VendorLegitimateInterests.clone()
+	clone.PublisherRestrictions = slices.Clone(p.PublisherRestrictions)
+	for i := range clone.PublisherRestrictions {
+		clone.PublisherRestrictions[i].RangeEntries = slices.Clone(p.PublisherRestrictions[i].RangeEntries)
+	}
+	if p.DisclosedVendorsSegment != nil {
+		clone.DisclosedVendorsSegment = p.DisclosedVendorsSegment.clone()
+	}
+	if p.AllowedVendorsSegment != nil {
+		clone.AllowedVendorsSegment = p.AllowedVendorsSegment.clone()
+	}
+	if p.PublisherTC != nil {
+		clone.PublisherTC = &PublisherTC{
+			PubPurposesConsent:           slices.Clone(p.PublisherTC.PubPurposesConsent),
+			PubPurposesLITransparency:    slices.Clone(p.PublisherTC.PubPurposesLITransparency),
+			NumCustomPurposes:            p.PublisherTC.NumCustomPurposes,
+			CustomPurposesConsent:        slices.Clone(p.PublisherTC.CustomPurposesConsent),
+			CustomPurposesLITransparency: slices.Clone(p.PublisherTC.CustomPurposesLITransparency),
+		}
+	}
+	return &clone
+}
+
+func (s *VendorSection) clone() *VendorSection {
+	clone := *s
+	clone.BitField = slices.Clone(s.BitField)
+	clone.RangeEntries = slices.Clone(s.RangeEntries)
+	clone.normalized = s.normalized.clone(s.RangeEntries, clone.RangeEntries)
+	return &clone
+}
+
+// Consent returns a deep copy of the underlying Consent, which can be modified freely
+//
+// note: it returns nil if the view has been built from a LazyConsent
+func (c *ImmutableConsent) Consent() *Consent {
+	if c.eager == nil {
+		return nil
+	}
+	return c.eager.Clone()
+}
+
+// view returns the underlying consent
+func (c *ImmutableConsent) view() ConsentView {
+	if c.eager != nil {
+		return c.eager
+	}
+	return c.lazy
+}
+
+// //////////////////////////////////////////////////
+// core fields
+
+// Version returns the version of the consent string
+func (c *ImmutableConsent) Version() int {
+	if c.eager != nil {
+		return c.eager.Version
+	}
+	return c.lazy.Version()
+}
+
+// Created returns the creation time of the consent string
+func (c *ImmutableConsent) Created() time.Time {
+	if c.eager != nil {
+		return c.eager.Created
+	}
+	return c.lazy.Created()
+}
+
+// LastUpdated returns the last update time of the consent string
+func (c *ImmutableConsent) LastUpdated() time.Time {
+	if c.eager != nil {
+		return c.eager.LastUpdated
+	}
+	return c.lazy.LastUpdated()
+}
+
+// CMPID returns the ID of the CMP which has created the consent string
+func (c *ImmutableConsent) CMPID() int {
+	if c.eager != nil {
+		return c.eager.CMPID
+	}
+	return c.lazy.CMPID()
+}
+
+// CMPVersion returns the version of the CMP which has created the consent string
+func (c *ImmutableConsent) CMPVersion() int {
+	if c.eager != nil {
+		return c.eager.CMPVersion
+	}
+	return c.lazy.CMPVersion()
+}
+
+// ConsentScreen returns the screen number in the CMP where consent was given
+func (c *ImmutableConsent) ConsentScreen() int {
+	if c.eager != nil {
+		return c.eager.ConsentScreen
+	}
+	return c.lazy.ConsentScreen()
+}
+
+// ConsentLanguage returns the two-letter ISO 639-1 language code of the CMP
+func (c *ImmutableConsent) ConsentLanguage() string {
+	if c.eager != nil {
+		return c.eager.ConsentLanguage
+	}
+	return c.lazy.ConsentLanguage()
+}
+
+// VendorListVersion returns the version of the global vendor list used
+func (c *ImmutableConsent) VendorListVersion() int {
+	if c.eager != nil {
+		return c.eager.VendorListVersion
+	}
+	return c.lazy.VendorListVersion()
+}
+
+// TcfPolicyVersion returns the version of the TCF policy used
+func (c *ImmutableConsent) TcfPolicyVersion() int {
+	if c.eager != nil {
+		return c.eager.TcfPolicyVersion
+	}
+	return c.lazy.TcfPolicyVersion()
+}
+
+// IsServiceSpecific checks if the consent is only valid for the service which has created it
+func (c *ImmutableConsent) IsServiceSpecific() bool {
+	if c.eager != nil {
+		return c.eager.IsServiceSpecific
+	}
+	return c.lazy.IsServiceSpecific()
+}
+
+// UseNonStandardStacks checks if the CMP has used non-IAB standard stacks
+func (c *ImmutableConsent) UseNonStandardStacks() bool {
+	if c.eager != nil {
+		return c.eager.UseNonStandardStacks
+	}
+	return c.lazy.UseNonStandardStacks()
+}
+
+// PurposeOneTreatment checks if purpose 1 was not disclosed to the user
+func (c *ImmutableConsent) PurposeOneTreatment() bool {
+	if c.eager != nil {
+		return c.eager.PurposeOneTreatment
+	}
+	return c.lazy.PurposeOneTreatment()
+}
+
+// PublisherCC returns the two-letter ISO 3166-1 country code of the publisher
+func (c *ImmutableConsent) PublisherCC() string {
+	if c.eager != nil {
+		return c.eager.PublisherCC
+	}
+	return c.lazy.PublisherCC()
+}
+
+// MaxVendorID returns the highest vendor ID of the consented vendors section
+func (c *ImmutableConsent) MaxVendorID() int {
+	if c.eager != nil {
+		return c.eager.MaxVendorID
+	}
+	return c.lazy.MaxVendorID()
+}
+
+// IsRangeEncoding checks if the consented vendors are encoded as range entries
+func (c *ImmutableConsent) IsRangeEncoding() bool {
+	if c.eager != nil {
+		return c.eager.IsRangeEncoding
+	}
+	return c.lazy.IsRangeEncoding()
+}
+
+// //////////////////////////////////////////////////
+// consent checks
+
+// EveryPurposeAllowed checks if every purpose of numbers is allowed
+func (c *ImmutableConsent) EveryPurposeAllowed(numbers []int) bool {
+	return c.view().EveryPurposeAllowed(numbers)
+}
+
+// PurposeAllowed checks if the purpose is allowed
+func (c *ImmutableConsent) PurposeAllowed(number int) bool {
+	return c.view().PurposeAllowed(number)
+}
+
+// PurposeLITransparencyAllowed checks if legitimate interest is established for the purpose
+func (c *ImmutableConsent) PurposeLITransparencyAllowed(number int) bool {
+	return c.view().PurposeLITransparencyAllowed(number)
+}
+
+// EverySpecialFeatureAllowed checks if every special feature of numbers is opted in
+func (c *ImmutableConsent) EverySpecialFeatureAllowed(numbers []int) bool {
+	return c.view().EverySpecialFeatureAllowed(numbers)
+}
+
+// SpecialFeatureAllowed checks if the special feature is opted in
+func (c *ImmutableConsent) SpecialFeatureAllowed(number int) bool {
+	return c.view().SpecialFeatureAllowed(number)
+}
+
+// VendorAllowed checks if the vendor is allowed
+func (c *ImmutableConsent) VendorAllowed(number int) bool {
+	return c.view().VendorAllowed(number)
+}
+
+// VendorsAllowed returns a bit set keyed by vendor ID, where the bit number of each allowed vendor of ids is set
+//
+// note: the returned Bits are a new value owned by the caller
+func (c *ImmutableConsent) VendorsAllowed(ids []int) Bits {
+	return c.view().VendorsAllowed(ids)
+}
+
+// FilterAllowed returns the allowed vendors of ids, in the same order
+func (c *ImmutableConsent) FilterAllowed(ids []int) []int {
+	return c.view().FilterAllowed(ids)
+}
+
+// ConsentedVendorIDs returns an iterator over the vendor IDs user has given his consent to
+func (c *ImmutableConsent) ConsentedVendorIDs() iter.Seq[int] {
+	return c.view().ConsentedVendorIDs()
+}
+
+// LIVendors returns an iterator over the vendor IDs for which legitimate interest is established
+func (c *ImmutableConsent) LIVendors() iter.Seq[int] {
+	return c.view().LIVendors()
+}
+
+// DisclosedVendors returns an iterator over the vendor IDs of the disclosed vendors segment
+func (c *ImmutableConsent) DisclosedVendors() iter.Seq[int] {
+	return c.view().DisclosedVendors()
+}
+
+// AllowedPurposes returns an iterator over the purpose IDs user has given his consent to
+func (c *ImmutableConsent) AllowedPurposes() iter.Seq[int] {
+	return c.view().AllowedPurposes()
+}
+
+// LogValue implements slog.LogValuer
+func (c *ImmutableConsent) LogValue() slog.Value {
+	if c.eager != nil {
+		return c.eager.LogValue()
+	}
+	return c.lazy.LogValue()
+}
+
+// clone returns the normalized copy of the cloned entries, if there's one of the original entries
+func (n *normalizedEntries) clone(entries, cloned []RangeEntry) normalizedEntries {
+	if _, ok := n.of(entries); !ok || len(entries) == 0 {
+		return normalizedEntries{}
+	}
+	return newNormalizedEntries(cloned)
+}
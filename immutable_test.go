@@ -0,0 +1,128 @@
+package iabtcf
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmutableConsent(t *testing.T) {
+
+	c := "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA.IF5EX2S5OI2tho2YdF7BEYYwfJxyigMgShgQIsS8NwIeFbBoGPmAAHBG4JAQAGBAkkACBAQIsHGBcCQABgIgRiRCMQEGMjzNKBJBAggkbI0FACCVmnkHS3ZCY70-6u__bA"
+
+	eager, err := ParseCoreString(c)
+	require.NoError(t, err, "unexpected error")
+	lazy, err := LazyParseCoreString(c)
+	require.NoError(t, err, "unexpected error")
+
+	for name, view := range map[string]*ImmutableConsent{"eager": eager.Immutable(), "lazy": lazy.Immutable()} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, eager.Version, view.Version())
+			require.Equal(t, eager.Created, view.Created())
+			require.Equal(t, eager.LastUpdated, view.LastUpdated())
+			require.Equal(t, eager.CMPID, view.CMPID())
+			require.Equal(t, eager.CMPVersion, view.CMPVersion())
+			require.Equal(t, eager.ConsentScreen, view.ConsentScreen())
+			require.Equal(t, eager.ConsentLanguage, view.ConsentLanguage())
+			require.Equal(t, eager.VendorListVersion, view.VendorListVersion())
+			require.Equal(t, eager.TcfPolicyVersion, view.TcfPolicyVersion())
+			require.Equal(t, eager.IsServiceSpecific, view.IsServiceSpecific())
+			require.Equal(t, eager.UseNonStandardStacks, view.UseNonStandardStacks())
+			require.Equal(t, eager.PurposeOneTreatment, view.PurposeOneTreatment())
+			require.Equal(t, eager.PublisherCC, view.PublisherCC())
+			require.Equal(t, eager.MaxVendorID, view.MaxVendorID())
+			require.Equal(t, eager.IsRangeEncoding, view.IsRangeEncoding())
+
+			require.True(t, view.VendorAllowed(423))
+			require.Equal(t, slices.Collect(eager.ConsentedVendorIDs()), slices.Collect(view.ConsentedVendorIDs()))
+			require.Equal(t, slices.Collect(eager.DisclosedVendors()), slices.Collect(view.DisclosedVendors()))
+			require.Equal(t, slices.Collect(eager.AllowedPurposes()), slices.Collect(view.AllowedPurposes()))
+			require.Equal(t, eager.FilterAllowed([]int{1, 2, 423}), view.FilterAllowed([]int{1, 2, 423}))
+			require.Equal(t, eager.LogValue().String(), view.LogValue().String())
+		})
+	}
+
+	// note: the views don't share memory with the parsed consents
+	eagerView, lazyView := eager.Immutable(), lazy.Immutable()
+	eager.RangeEntries[0].EndVendorID = 0
+	eager.DisclosedVendorsSegment.BitField[0] = 0
+	clear(lazy.Core)
+	require.True(t, eagerView.VendorAllowed(423))
+	require.True(t, lazyView.VendorAllowed(423))
+	require.NotEmpty(t, slices.Collect(eagerView.DisclosedVendors()))
+
+	// note: Consent returns a copy, which can be modified without altering the view
+	copied := eagerView.Consent()
+	copied.RangeEntries = nil
+	require.True(t, eagerView.VendorAllowed(423))
+	require.Nil(t, lazyView.Consent())
+}
+
+func TestConsentClone(t *testing.T) {
+
+	c := encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(testPublisherTCBits)
+	p, err := ParseCoreString(c)
+	require.NoError(t, err, "unexpected error")
+	want, err := ParseCoreString(c)
+	require.NoError(t, err, "unexpected error")
+
+	clone := p.Clone()
+	require.Equal(t, p, clone)
+
+	clear(clone.PurposesConsent)
+	clear(clone.VendorLegitimateInterests.BitField)
+	clear(clone.DisclosedVendorsSegment.BitField)
+	clear(clone.PublisherTC.PubPurposesConsent)
+	require.NotEqual(t, p, clone, "the clone must not share memory with the consent")
+	require.Equal(t, want, p)
+}
+
+func TestImmutableConsentConcurrency(t *testing.T) {
+
+	lazy, err := LazyParseCoreString(rangeEncodedConsent(t, 1000))
+	require.NoError(t, err, "unexpected error")
+	view := lazy.Immutable()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := 1; id < 2000; id++ {
+				if view.VendorAllowed(id) != (id%2 == 0) {
+					t.Errorf("unexpected consent for vendor %d", id)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParseImmutable(t *testing.T) {
+
+	c := "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA.IF5EX2S5OI2tho2YdF7BEYYwfJxyigMgShgQIsS8NwIeFbBoGPmAAHBG4JAQAGBAkkACBAQIsHGBcCQABgIgRiRCMQEGMjzNKBJBAggkbI0FACCVmnkHS3ZCY70-6u__bA"
+
+	eager, err := ParseImmutable(c)
+	require.NoError(t, err, "unexpected error")
+	lazy, err := LazyParseImmutable(c)
+	require.NoError(t, err, "unexpected error")
+	for _, view := range []*ImmutableConsent{eager, lazy} {
+		require.True(t, view.VendorAllowed(423))
+		require.Equal(t, 92, view.CMPID())
+	}
+	require.NotNil(t, eager.Consent())
+
+	_, err = ParseImmutable("")
+	require.EqualError(t, err, "consent string is empty")
+	_, err = LazyParseImmutable("")
+	require.EqualError(t, err, "consent string is empty")
+
+	// note: the parsed consent is wrapped, not copied
+	parseAllocs := testing.AllocsPerRun(100, func() { _, _ = ParseCoreString(c) })
+	require.Equal(t, parseAllocs+1, testing.AllocsPerRun(100, func() { _, _ = ParseImmutable(c) }))
+	lazyParseAllocs := testing.AllocsPerRun(100, func() { _, _ = LazyParseCoreString(c) })
+	require.Equal(t, lazyParseAllocs+1, testing.AllocsPerRun(100, func() { _, _ = LazyParseImmutable(c) }))
+}
@@ -0,0 +1,219 @@
+package iabtcf
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// //////////////////////////////////////////////////
+// consent iterators
+//
+// note: every iterator yields IDs in ascending order and without duplicates,
+// whatever the encoding ( bitfield or range entries ) of the underlying section.
+
+// ConsentedVendorIDs returns an iterator over the vendor IDs user has given his consent to
+//
+// note: it's not named ConsentedVendors since this is the name of the raw bitfield,
+// LazyConsent using the same name so both are a ConsentView.
+func (p *Consent) ConsentedVendorIDs() iter.Seq[int] {
+	if p.IsRangeEncoding {
+		return rangeEntriesSeq(p.RangeEntries)
+	}
+	return bitsSeq(p.ConsentedVendors, p.MaxVendorID)
+}
+
+// LIVendors returns an iterator over the vendor IDs for which legitimate interest is established
+func (p *Consent) LIVendors() iter.Seq[int] {
+	return p.VendorLegitimateInterests.IDs()
+}
+
+// DisclosedVendors returns an iterator over the vendor IDs of the disclosed vendors segment
+//
+// note: the iterator is empty if there is no disclosed vendors segment.
+// As for LazyConsent.IsVendorDisclosed, the IDs are bounded by the max vendor ID of the segment, whatever its encoding.
+func (p *Consent) DisclosedVendors() iter.Seq[int] {
+	s := p.DisclosedVendorsSegment
+	if s == nil {
+		return func(yield func(int) bool) {}
+	}
+	return upToSeq(s.IDs(), s.MaxVendorID)
+}
+
+// AllowedPurposes returns an iterator over the purpose IDs user has given his consent to
+func (p *Consent) AllowedPurposes() iter.Seq[int] {
+	return bitsSeq(p.PurposesConsent, PurposesConsentField.NbBits)
+}
+
+// IDs returns an iterator over the vendor IDs of the section
+func (s *VendorSection) IDs() iter.Seq[int] {
+	if s == nil {
+		return func(yield func(int) bool) {}
+	}
+	if s.IsRangeEncoding {
+		return rangeEntriesSeq(s.RangeEntries)
+	}
+	return bitsSeq(s.BitField, s.MaxVendorID)
+}
+
+// //////////////////////////////////////////////////
+// lazy consent iterators
+
+// ConsentedVendorIDs returns an iterator over the vendor IDs user has given his consent to
+func (c *LazyConsent) ConsentedVendorIDs() iter.Seq[int] {
+	return c.Core.vendorSectionSeq(MaxVendorIDField.Offset)
+}
+
+// LIVendors returns an iterator over the vendor IDs for which legitimate interest is established
+//
+// note: the vendor consent section is walked to find the offset of the legitimate interest section.
+func (c *LazyConsent) LIVendors() iter.Seq[int] {
+	return c.Core.vendorSectionSeq(c.Core.vendorSectionEnd(MaxVendorIDField.Offset))
+}
+
+// DisclosedVendors returns an iterator over the vendor IDs of all the disclosed vendors blocks
+//
+// note: the iterator is empty if there is no disclosed vendors block.
+// As for IsVendorDisclosed, the IDs of each block are bounded by its max vendor ID, whatever its encoding.
+func (c *LazyConsent) DisclosedVendors() iter.Seq[int] {
+	var blocks []Bits
+	for _, block := range c.Extras {
+		if block.ReadIntField(0, 3) == disclosedVendorsSegmentType {
+			blocks = append(blocks, block)
+		}
+	}
+	if len(blocks) == 1 {
+		return upToSeq(blocks[0].vendorSectionSeq(3), blocks[0].ReadIntField(3, 16))
+	}
+
+	// note: several blocks are merged to keep IDs sorted and unique
+	var ids []int
+	for _, block := range blocks {
+		ids = slices.AppendSeq(ids, upToSeq(block.vendorSectionSeq(3), block.ReadIntField(3, 16)))
+	}
+	slices.Sort(ids)
+	return slices.Values(slices.Compact(ids))
+}
+
+// AllowedPurposes returns an iterator over the purpose IDs user has given his consent to
+func (c *LazyConsent) AllowedPurposes() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for number := 1; number <= PurposesConsentField.NbBits; number++ {
+			if c.PurposeAllowed(number) && !yield(number) {
+				return
+			}
+		}
+	}
+}
+
+// //////////////////////////////////////////////////
+// iterator helpers
+
+// bitsSeq returns an iterator over the numbers of the bits set in b, up to maxNumber
+func bitsSeq(b Bits, maxNumber int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for number := range b.All() {
+			if number > maxNumber || !yield(number) {
+				return
+			}
+		}
+	}
+}
+
+// upToSeq returns an iterator over the IDs of the sorted iterator seq, up to maxID
+func upToSeq(seq iter.Seq[int], maxID int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for id := range seq {
+			if id > maxID || !yield(id) {
+				return
+			}
+		}
+	}
+}
+
+// rangeEntriesSeq returns an iterator over the IDs covered by the range entries
+//
+// note: entries are not expected to be sorted nor disjoint
+func rangeEntriesSeq(entries []RangeEntry) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		sorted := entries
+		byStart := func(a, b RangeEntry) int { return cmp.Compare(a.StartOrOnlyVendorId, b.StartOrOnlyVendorId) }
+		if !slices.IsSortedFunc(sorted, byStart) {
+			sorted = slices.SortedFunc(slices.Values(entries), byStart)
+		}
+		next := 1
+		for _, e := range sorted {
+			for id := max(e.StartOrOnlyVendorId, next); id <= e.EndVendorID; id++ {
+				if !yield(id) {
+					return
+				}
+			}
+			next = max(next, e.EndVendorID+1)
+		}
+	}
+}
+
+// vendorSectionSeq returns an iterator over the vendor IDs of the vendor section starting at offset
+//
+// note: as for LazyConsent.VendorAllowed, the bits out of bound are considered as not set,
+// so they are not walked.
+func (b Bits) vendorSectionSeq(offset int) iter.Seq[int] {
+	maxVendorID := b.ReadIntField(offset, 16)
+	if !b.ReadBoolField(offset + 16) {
+		return func(yield func(int) bool) {
+			for number := 1; number <= min(maxVendorID, b.Length()-offset-17); number++ {
+				if b.ReadBitNumber(number, offset+17, maxVendorID) && !yield(number) {
+					return
+				}
+			}
+		}
+	}
+	return rangeEntriesSeq(b.readRangeEntries(offset + 17))
+}
+
+// vendorSectionEnd returns the offset following the vendor section starting at offset
+func (b Bits) vendorSectionEnd(offset int) int {
+	maxVendorID := b.ReadIntField(offset, 16)
+	if !b.ReadBoolField(offset + 16) {
+		return offset + 17 + maxVendorID
+	}
+	numEntries := b.ReadIntField(offset+17, 12)
+	offset += 17 + 12
+	for i := range numEntries {
+		if offset >= b.Length() {
+			// note: the next entries are out of bound, so they are single vendor entries of 17 bits
+			return offset + (numEntries-i)*17
+		}
+		isRange := b.ReadBoolField(offset)
+		offset += 1 + 16
+		if isRange {
+			offset += 16
+		}
+	}
+	return offset
+}
+
+// readRangeEntries reads the number of range entries at offset, then each range entries
+//
+// note: the entries out of bound are skipped, so the number of entries read is bounded by the length of b
+func (b Bits) readRangeEntries(offset int) []RangeEntry {
+	numEntries := b.ReadIntField(offset, 12)
+	offset += 12
+	entries := make([]RangeEntry, 0, min(numEntries, max(b.Length()-offset, 0)/17+1))
+	for range numEntries {
+		if offset >= b.Length() {
+			break
+		}
+		isRange := b.ReadBoolField(offset)
+		offset++
+		start := b.ReadIntField(offset, 16)
+		offset += 16
+		end := start
+		if isRange {
+			end = b.ReadIntField(offset, 16)
+			offset += 16
+		}
+		entries = append(entries, RangeEntry{StartOrOnlyVendorId: start, EndVendorID: end})
+	}
+	return entries
+}
@@ -0,0 +1,101 @@
+package iabtcf
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterators(t *testing.T) {
+
+	type TestCase struct {
+		consent              string
+		wantConsentedVendors []int
+		wantLIVendors        []int
+		wantDisclosedVendors []int
+		wantAllowedPurposes  []int
+	}
+
+	testCases := map[string]*TestCase{
+		"bitfield": {
+			consent:              encodeBits(testCoreBits),
+			wantConsentedVendors: []int{1, 5},
+			wantLIVendors:        []int{10, 11, 12},
+			wantAllowedPurposes:  []int{1, 2, 3},
+		},
+		"with-segments": {
+			consent:              encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(testPublisherTCBits),
+			wantConsentedVendors: []int{1, 5},
+			wantLIVendors:        []int{10, 11, 12},
+			wantDisclosedVendors: []int{1, 2, 5},
+			wantAllowedPurposes:  []int{1, 2, 3},
+		},
+		"range-encoding": {
+			consent:              "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA",
+			wantConsentedVendors: []int{423},
+			wantAllowedPurposes:  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+		"disclosed-range-above-max-vendor-id": {
+			consent:              encodeBits(testCoreBits) + "." + encodeBits(sprintb(1, 3)+sprintb(8, 16)+"1"+sprintb(2, 12)+"0"+sprintb(3, 16)+"1"+sprintb(6, 16)+sprintb(12, 16)),
+			wantConsentedVendors: []int{1, 5},
+			wantLIVendors:        []int{10, 11, 12},
+			wantDisclosedVendors: []int{3, 6, 7, 8},
+			wantAllowedPurposes:  []int{1, 2, 3},
+		},
+		"unsorted-ranges": {
+			consent: encodeBits(testCoreBits[:MaxVendorIDField.Offset] +
+				sprintb(30, 16) + "1" + sprintb(3, 12) + "1" + sprintb(20, 16) + sprintb(22, 16) + "0" + sprintb(3, 16) + "1" + sprintb(21, 16) + sprintb(30, 16) +
+				sprintb(0, 16) + "0" + sprintb(0, 12)),
+			wantConsentedVendors: []int{3, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30},
+			wantAllowedPurposes:  []int{1, 2, 3},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := ParseCoreString(tc.consent)
+			require.NoError(t, err, "unexpected parse error")
+			lazy, err := LazyParseCoreString(tc.consent)
+			require.NoError(t, err, "unexpected lazy parse error")
+
+			require.Equal(t, tc.wantConsentedVendors, slices.Collect(parsed.ConsentedVendorIDs()), "wrong consented vendors")
+			require.Equal(t, tc.wantConsentedVendors, slices.Collect(lazy.ConsentedVendorIDs()), "wrong lazy consented vendors")
+			require.Equal(t, tc.wantLIVendors, slices.Collect(parsed.LIVendors()), "wrong li vendors")
+			require.Equal(t, tc.wantLIVendors, slices.Collect(lazy.LIVendors()), "wrong lazy li vendors")
+			require.Equal(t, tc.wantDisclosedVendors, slices.Collect(parsed.DisclosedVendors()), "wrong disclosed vendors")
+			require.Equal(t, tc.wantDisclosedVendors, slices.Collect(lazy.DisclosedVendors()), "wrong lazy disclosed vendors")
+			for id := 1; id <= 20; id++ {
+				_, want := slices.BinarySearch(tc.wantDisclosedVendors, id)
+				require.Equal(t, want, parsed.VendorDisclosed(id), "vendor disclosed %d", id)
+				require.Equal(t, want, lazy.IsVendorDisclosed(id), "lazy vendor disclosed %d", id)
+			}
+			require.Equal(t, tc.wantAllowedPurposes, slices.Collect(parsed.AllowedPurposes()), "wrong allowed purposes")
+			require.Equal(t, tc.wantAllowedPurposes, slices.Collect(lazy.AllowedPurposes()), "wrong lazy allowed purposes")
+		})
+	}
+}
+
+func TestIteratorsBigConsent(t *testing.T) {
+
+	// note: this consent string contains more than 4000 vendors
+	c := "CP9Qr_AP9Qr_AAfETDFRAwEsAP_gAEPgAAigg1NX_H__bX9v-Xr36ft0eY1f99j77uQxBhfJs-4FzLvW_JwX32EzNE36tqYKmRIEu3bBIQFtHJnUTVihaogVrzHsYkGchTNKJ-BkiHMRe2dYCF5vmYtj-QKZ5_p_d3f52T_9_dv-3dzzz91nv3f9f-f1eLida59tH_v_bRKb-_If9_7-_4v0_t_rk2_eTVv_9evv79-u_t____9_9____4AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAAAEQamr_j__tr-3_L179P26PMav--x993IYgwvk2fcC5l3rfk4L77CZmib9W1MFTIkCXbtgkIC2jkzqJqxQtUQK15j2MSDOQpmlE_AyRDmIvbOsBC83zMWx_IFM8_0_u7v87J_-_u3_bu555-6z37v-v_P6vFxOtc-2j_3_tolN_fkP-_9_f8X6f2_1ybfvJq3_-vX39-_Xf2____-_-____8AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAIAAACAA"
+
+	parsed, err := ParseCoreString(c)
+	require.NoError(t, err, "unexpected parse error")
+	lazy, err := LazyParseCoreString(c)
+	require.NoError(t, err, "unexpected lazy parse error")
+
+	consented := slices.Collect(parsed.ConsentedVendorIDs())
+	require.Equal(t, consented, slices.Collect(lazy.ConsentedVendorIDs()), "wrong lazy consented vendors")
+	for _, id := range consented {
+		require.True(t, parsed.VendorAllowed(id), "vendor %d", id)
+	}
+	require.Equal(t, slices.Collect(parsed.LIVendors()), slices.Collect(lazy.LIVendors()), "wrong lazy li vendors")
+
+	// early stop
+	for id := range lazy.ConsentedVendorIDs() {
+		require.Equal(t, 1, id)
+		break
+	}
+}
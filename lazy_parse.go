@@ -3,7 +3,9 @@ package iabtcf
 import (
 	"encoding/base64"
 	"fmt"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,24 +19,67 @@ import (
 // For the vendor part, if the consent string is too short or invalid, the vendor will be considered as not allowed.
 //
 // note: the lazy parser is optimized for checking only one vendor + few fields
+//
+// note: the Observer set by SetObserver, if any, is notified of the outcome.
 func LazyParseCoreString(c string) (*LazyConsent, error) {
+	consent := &LazyConsent{}
+	if err := LazyParseInto(consent, c); err != nil {
+		return nil, err
+	}
+	return consent, nil
+}
+
+// LazyParseInto parses a TCF consent string into dst, as LazyParseCoreString does
+//
+// The backing arrays of dst.Core and dst.Extras are reused, so parsing strings of similar length
+// into the same dst performs no allocation once the buffers have grown.
+// The segments are iterated without splitting the string, and base64 is decoded in place.
+//
+// note: dst must not be used concurrently, and the Bits previously read from dst are overwritten.
+// On error, dst is reset to an empty consent.
+func LazyParseInto(dst *LazyConsent, c string) error {
+	err := lazyParseInto(dst, c)
+	if err != nil {
+		dst.Core = dst.Core[:0]
+		dst.Extras = dst.Extras[:0]
+	}
+	if o := loadObserver(); o != nil {
+		e := newParseEvent(LazyParser, c, err)
+		if err == nil {
+			e.CMPID, e.IsRangeEncoding = dst.CMPID(), dst.IsRangeEncoding()
+		}
+		o.ObserveParse(e)
+	}
+	return err
+}
+
+func lazyParseInto(dst *LazyConsent, c string) error {
+	dst.vendorIndex.Store(nil)
 	if c == "" {
-		return nil, fmt.Errorf("consent string is empty")
+		return fmt.Errorf("consent string is empty")
 	}
-	everything := strings.Split(c, ".")
 
 	// extract core string
-	var bytes, err = base64.RawURLEncoding.DecodeString(everything[0])
-	if err != nil {
-		return nil, fmt.Errorf("decode failed: %w", err)
+	core, extras, _ := strings.Cut(c, ".")
+	var err error
+	if dst.Core, err = decodeInto(dst.Core, core); err != nil {
+		return fmt.Errorf("decode failed: %w", err)
 	}
 
 	// Extract disclosed vendors and publisher TC blocks.  There are an arbitrary number of these blocks in any order,
 	// and each block needs to be inspected to see what it is.
-	consent := NewLazyConsent(bytes)
-	for _, extra := range everything[1:] {
-		if bytes, err := base64.RawURLEncoding.DecodeString(extra); err == nil {
-			consent.Extras = append(consent.Extras, Bits(bytes))
+	// note: the buffers of the previous extras are kept beyond the length of the slice, to be reused
+	dst.Extras = dst.Extras[:0]
+	for extras != "" {
+		var extra string
+		extra, extras, _ = strings.Cut(extras, ".")
+
+		var buf Bits
+		if n := len(dst.Extras); n < cap(dst.Extras) {
+			buf = dst.Extras[:n+1][n]
+		}
+		if buf, err = decodeInto(buf, extra); err == nil {
+			dst.Extras = append(dst.Extras, buf)
 		}
 	}
 
@@ -42,20 +87,65 @@ func LazyParseCoreString(c string) (*LazyConsent, error) {
 	// after this bit, we will have either range entries ( up to num_entries ) or vendor bitset ( up to max_vendor_id ).
 	// we are just checking here that we are able to read at minimum the fixed fields.
 	// if after this bit, the consent string is too short or invalid, we will just return that the vendor is not allowed
-	if consent.Core.Length() < IsRangeEncodingField.NextOffset() {
-		return nil, fmt.Errorf("consent string is too short")
+	if dst.Core.Length() < IsRangeEncodingField.NextOffset() {
+		return errTooShort
 	}
 
-	return consent, nil
+	return nil
+}
+
+// decodeChunkSize is the number of base64 characters decoded at once by decodeInto, a multiple of 4
+const decodeChunkSize = 256
+
+// decodeInto decodes the base64 string s into the backing array of buf, which grows if too small
+//
+// note: s is copied into a stack buffer and decoded by chunks, so converting it to a []byte doesn't allocate.
+// Since a chunk is a multiple of 4 characters, it's decoded into whole bytes.
+// The decoder skips the new lines, which would shift the chunks, so a string containing some is decoded at once.
+func decodeInto(buf Bits, s string) (Bits, error) {
+	n := base64.RawURLEncoding.DecodedLen(len(s))
+	if cap(buf) < n {
+		buf = make(Bits, n)
+	}
+	buf = buf[:n]
+
+	if strings.ContainsAny(s, "\r\n") {
+		decoded, err := base64.RawURLEncoding.Decode(buf, []byte(s))
+		if err != nil {
+			return buf[:0], err
+		}
+		return buf[:decoded], nil
+	}
+
+	var chunk [decodeChunkSize]byte
+	n = 0
+	for i := 0; i < len(s); i += decodeChunkSize {
+		decoded, err := base64.RawURLEncoding.Decode(buf[n:], chunk[:copy(chunk[:], s[i:])])
+		if err != nil {
+			if corrupt, ok := err.(base64.CorruptInputError); ok {
+				// note: the offset is the one in s, not in the chunk
+				err = corrupt + base64.CorruptInputError(i)
+			}
+			// note: the buffer is returned so it can still be reused
+			return buf[:0], err
+		}
+		n += decoded
+	}
+	return buf[:n], nil
 }
 
 // //////////////////////////////////////////////////
 // lazy consent
 
 // LazyConsent provides methods to extract data in a lazy mode ( to avoid parsing all fields for nothing )
+//
+// note: it must not be copied after first use, since it may hold the vendor index of its range entries
 type LazyConsent struct {
 	Core   Bits
 	Extras []Bits
+
+	// vendorIndex is built on first use of VendorAllowed when there are many range entries
+	vendorIndex atomic.Pointer[rangeIndex]
 }
 
 func NewLazyConsent(bytes []byte) *LazyConsent {
@@ -190,6 +280,9 @@ func (c *LazyConsent) SpecialFeatureAllowed(number int) bool {
 }
 
 // VendorAllowed checks if vendor is in the list of vendors user has given his consent to
+//
+// note: when there are more than rangeIndexThreshold range entries, they are decoded once into a compact index,
+// which is then looked up by binary search.
 func (c *LazyConsent) VendorAllowed(number int) bool {
 
 	if c.IsRangeEncoding() {
@@ -198,10 +291,18 @@ func (c *LazyConsent) VendorAllowed(number int) bool {
 		if numEntries == 0 {
 			return false
 		}
+		if numEntries > rangeIndexThreshold {
+			return c.rangeIndex().contains(number)
+		}
 
 		offset := NumRangeEntriesField.NextOffset()
 		for i := 0; i < int(numEntries); i++ {
 
+			if offset >= c.Core.Length() {
+				// note: the next entries are out of bound, so they are empty
+				break
+			}
+
 			isRange := c.Core.ReadBoolField(offset)
 			offset += 1
 
@@ -230,6 +331,36 @@ func (c *LazyConsent) VendorAllowed(number int) bool {
 	return c.Core.ReadBitNumber(number, ConsentedVendorsOffset, maxVendorId)
 }
 
+// rangeIndexThreshold is the number of range entries above which VendorAllowed builds an index
+const rangeIndexThreshold = 16
+
+// rangeIndex is a compact index of normalized range entries, each entry being packed as end << 16 | start
+//
+// note: since entries are sorted and disjoint, both starts and ends are increasing, so are the packed entries
+type rangeIndex []uint32
+
+// rangeIndex returns the index of the vendor consent range entries, building it on first use
+//
+// note: concurrent first uses may build the index several times, only one of them is kept
+func (c *LazyConsent) rangeIndex() rangeIndex {
+	if index := c.vendorIndex.Load(); index != nil {
+		return *index
+	}
+	entries := NormalizeRangeEntries(c.Core.readRangeEntries(NumRangeEntriesField.Offset))
+	index := make(rangeIndex, len(entries))
+	for i, e := range entries {
+		index[i] = uint32(e.EndVendorID)<<16 | uint32(e.StartOrOnlyVendorId)
+	}
+	c.vendorIndex.Store(&index)
+	return index
+}
+
+// contains checks if the number is covered by one of the entries
+func (index rangeIndex) contains(number int) bool {
+	i := sort.Search(len(index), func(i int) bool { return int(index[i]>>16) >= number })
+	return i < len(index) && int(index[i]&0xFFFF) <= number
+}
+
 // //////////////////////////////////////////////////
 // consent field helpers
 
@@ -1,6 +1,7 @@
 package iabtcf
 
 import (
+	"encoding/base64"
 	"fmt"
 	"testing"
 
@@ -74,3 +75,114 @@ func TestLazyParse(t *testing.T) {
 	}
 
 }
+
+func TestLazyParseInto(t *testing.T) {
+
+	const small = "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA"
+	const disclosed = "IF5EX2S5OI2tho2YdF7BEYYwfJxyigMgShgQIsS8NwIeFbBoGPmAAHBG4JAQAGBAkkACBAQIsHGBcCQABgIgRiRCMQEGMjzNKBJBAggkbI0FACCVmnkHS3ZCY70-6u__bA"
+
+	var dst LazyConsent
+
+	// parsing a string with segments, then a string without, must not keep the previous segments
+	for _, c := range []string{small + "." + disclosed + ".!invalid!", small, small + "." + disclosed} {
+		want, err := LazyParseCoreString(c)
+		require.NoError(t, err, "unexpected error")
+		require.NoError(t, LazyParseInto(&dst, c), "unexpected error")
+		require.Equal(t, want.Core, dst.Core)
+		require.Equal(t, len(want.Extras), len(dst.Extras))
+		for i := range want.Extras {
+			require.Equal(t, want.Extras[i], dst.Extras[i])
+		}
+		require.True(t, dst.VendorAllowed(423))
+	}
+
+	// on error, dst is reset
+	require.EqualError(t, LazyParseInto(&dst, "A"), "decode failed: illegal base64 data at input byte 0")
+	require.Empty(t, dst.Core)
+	require.Empty(t, dst.Extras)
+	require.EqualError(t, LazyParseInto(&dst, "COzcJxTOzcJx"), "consent string is too short")
+	require.Empty(t, dst.Core)
+	require.EqualError(t, LazyParseInto(&dst, ""), "consent string is empty")
+}
+
+func TestLazyParseIntoAllocations(t *testing.T) {
+
+	for _, c := range []string{
+		"COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA.IF5EX2S5OI2tho2YdF7BEYYwfJxyigMgShgQIsS8NwIeFbBoGPmAAHBG4JAQAGBAkkACBAQIsHGBcCQABgIgRiRCMQEGMjzNKBJBAggkbI0FACCVmnkHS3ZCY70-6u__bA",
+		// note: the core string is longer than a decoded chunk
+		rangeEncodedConsent(t, 200),
+	} {
+		var dst LazyConsent
+		require.NoError(t, LazyParseInto(&dst, c), "unexpected error")
+
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := LazyParseInto(&dst, c); err != nil {
+				t.Fatal(err)
+			}
+		})
+		require.Zero(t, allocs, "steady state parsing must not allocate")
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	valid := rangeEncodedConsent(t, 200)
+	require.Greater(t, len(valid), 2*decodeChunkSize)
+
+	var buf Bits
+	// note: the decoder skips the new lines, so they must not shift the chunks
+	withNewLines := valid[:decodeChunkSize-2] + "\n" + valid[decodeChunkSize-2:decodeChunkSize+5] + "\r\n" + valid[decodeChunkSize+5:]
+	for _, s := range []string{"", "A", "AA", valid[:decodeChunkSize], valid[:decodeChunkSize+1], valid, valid[:300] + "!" + valid[301:], valid + "A",
+		withNewLines, withNewLines + "!", "\n"} {
+		want, wantErr := base64.RawURLEncoding.DecodeString(s)
+		got, err := decodeInto(buf, s)
+		require.Equal(t, wantErr, err, "wrong error for %q", s)
+		if wantErr == nil {
+			require.Equal(t, string(want), string(got), "wrong decoded bytes for %q", s)
+			buf = got
+		}
+	}
+}
+
+func TestLazyParseNewLines(t *testing.T) {
+	valid := rangeEncodedConsent(t, 200)
+	want, err := LazyParseCoreString(valid)
+	require.NoError(t, err, "unexpected error")
+
+	got, err := LazyParseCoreString(valid[:decodeChunkSize-1] + "\n" + valid[decodeChunkSize-1:])
+	require.NoError(t, err, "unexpected error")
+	require.Equal(t, want.Core, got.Core)
+}
+
+// rangeEncodedConsent returns a range encoded consent string with numEntries entries, vendors 2, 4, 6, ... being allowed
+func rangeEncodedConsent(b testing.TB, numEntries int) string {
+	entries := make([]RangeEntry, numEntries)
+	for i := range entries {
+		entries[i] = RangeEntry{StartOrOnlyVendorId: 2 * (i + 1), EndVendorID: 2 * (i + 1)}
+	}
+	c, err := EncodeCoreString(&Consent{
+		Version:         2,
+		ConsentLanguage: "EN",
+		PublisherCC:     "AA",
+		MaxVendorID:     2 * numEntries,
+		IsRangeEncoding: true,
+		RangeEntries:    entries,
+	})
+	require.NoError(b, err, "unexpected encode error")
+	return c
+}
+
+func BenchmarkLazyConsentVendorAllowed(b *testing.B) {
+	for _, numEntries := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("range-%d-entries", numEntries), func(b *testing.B) {
+			consent, err := LazyParseCoreString(rangeEncodedConsent(b, numEntries))
+			require.NoError(b, err, "unexpected parse error")
+
+			// note: an odd vendor is never allowed, so every entry is read
+			for b.Loop() {
+				if consent.VendorAllowed(1) {
+					b.Fatal("vendor 1 must not be allowed")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Package macro expands the consent macros of URL templates, as used by ad tags and pixels
+//
+// The following macros are supported:
+//   - ${GDPR}: 1 if GDPR applies, 0 if it doesn't, empty if unknown
+//   - ${GDPR_CONSENT_XXXX}: the TC String, where XXXX is the ID of the vendor calling the URL
+//   - ${ADDTL_CONSENT}: the Google Additional Consent string
+//
+// Unknown macros are left unchanged.
+//
+// It's implemented according to specification: https://github.com/InteractiveAdvertisingBureau/GDPR-Transparency-and-Consent-Framework/blob/master/TCFv2/IAB%20Tech%20Lab%20-%20Consent%20string%20and%20vendor%20list%20formats%20v2.md#how-should-a-transparency--consent-string-be-passed-through-a-url
+package macro
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/travelaudience/go-iabtcf"
+)
+
+const (
+	gdprMacro           = "GDPR"
+	gdprConsentPrefix   = "GDPR_CONSENT_"
+	addtlConsentMacro   = "ADDTL_CONSENT"
+	macroStart          = "${"
+	macroEnd            = "}"
+	gdprAppliesValue    = "1"
+	gdprNotAppliesValue = "0"
+)
+
+// Params holds the consent data substituted to the macros
+type Params struct {
+	// GDPRApplies is nil when unknown
+	GDPRApplies *bool
+	// ConsentString is the raw TC String
+	ConsentString string
+	// Consent is the parsed ConsentString, it's used to check if the vendor of ${GDPR_CONSENT_XXXX} is allowed
+	Consent iabtcf.ConsentView
+	// AddtlConsent is the raw Google Additional Consent string
+	AddtlConsent string
+}
+
+// Expand returns the template with its consent macros substituted
+//
+// note: when GDPR applies, ${GDPR_CONSENT_XXXX} is suppressed ( substituted by an empty string )
+// if vendor XXXX is not allowed, or if the consent string is missing or not parsed,
+// so the consent string is only sent to the vendors user has given his consent to.
+func Expand(template string, p Params) string {
+	if !strings.Contains(template, macroStart) {
+		return template
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(template) + len(p.ConsentString))
+	for {
+		before, after, found := strings.Cut(template, macroStart)
+		sb.WriteString(before)
+		if !found {
+			return sb.String()
+		}
+		name, rest, found := strings.Cut(after, macroEnd)
+		if !found {
+			sb.WriteString(macroStart)
+			sb.WriteString(after)
+			return sb.String()
+		}
+		if value, ok := p.value(name); ok {
+			sb.WriteString(value)
+		} else {
+			sb.WriteString(macroStart)
+			sb.WriteString(name)
+			sb.WriteString(macroEnd)
+		}
+		template = rest
+	}
+}
+
+// value returns the value of the macro, false if it's unknown
+func (p *Params) value(name string) (string, bool) {
+	switch name {
+	case gdprMacro:
+		if p.GDPRApplies == nil {
+			return "", true
+		}
+		if *p.GDPRApplies {
+			return gdprAppliesValue, true
+		}
+		return gdprNotAppliesValue, true
+	case addtlConsentMacro:
+		return url.QueryEscape(p.AddtlConsent), true
+	}
+
+	id, ok := strings.CutPrefix(name, gdprConsentPrefix)
+	if !ok {
+		return "", false
+	}
+	vendorID, err := strconv.Atoi(id)
+	if err != nil || vendorID < 1 {
+		return "", false
+	}
+	if p.GDPRApplies != nil && *p.GDPRApplies && (p.Consent == nil || !p.Consent.VendorAllowed(vendorID)) {
+		return "", true
+	}
+	return url.QueryEscape(p.ConsentString), true
+}
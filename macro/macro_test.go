@@ -0,0 +1,83 @@
+package macro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/travelaudience/go-iabtcf"
+)
+
+const testConsent = "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA"
+
+func TestExpand(t *testing.T) {
+
+	consent, err := iabtcf.LazyParseCoreString(testConsent)
+	require.NoError(t, err, "unexpected parse error")
+
+	applies, notApplies := true, false
+
+	type TestCase struct {
+		template string
+		params   Params
+		want     string
+	}
+
+	testCases := map[string]*TestCase{
+		"no-macro": {
+			template: "https://example.com/pixel?x=1",
+			params:   Params{GDPRApplies: &applies},
+			want:     "https://example.com/pixel?x=1",
+		},
+		"allowed-vendor": {
+			template: "https://example.com/pixel?gdpr=${GDPR}&gdpr_consent=${GDPR_CONSENT_423}&addtl_consent=${ADDTL_CONSENT}",
+			params:   Params{GDPRApplies: &applies, ConsentString: testConsent, Consent: consent, AddtlConsent: "1~1.35"},
+			want:     "https://example.com/pixel?gdpr=1&gdpr_consent=" + testConsent + "&addtl_consent=1~1.35",
+		},
+		"suppressed-vendor": {
+			template: "https://example.com/pixel?gdpr=${GDPR}&gdpr_consent=${GDPR_CONSENT_1}",
+			params:   Params{GDPRApplies: &applies, ConsentString: testConsent, Consent: consent},
+			want:     "https://example.com/pixel?gdpr=1&gdpr_consent=",
+		},
+		"suppressed-without-parsed-consent": {
+			template: "gdpr_consent=${GDPR_CONSENT_423}",
+			params:   Params{GDPRApplies: &applies, ConsentString: testConsent},
+			want:     "gdpr_consent=",
+		},
+		"gdpr-not-applies": {
+			template: "gdpr=${GDPR}&gdpr_consent=${GDPR_CONSENT_1}",
+			params:   Params{GDPRApplies: &notApplies, ConsentString: testConsent, Consent: consent},
+			want:     "gdpr=0&gdpr_consent=" + testConsent,
+		},
+		"gdpr-unknown": {
+			template: "gdpr=${GDPR}&gdpr_consent=${GDPR_CONSENT_1}",
+			params:   Params{ConsentString: testConsent},
+			want:     "gdpr=&gdpr_consent=" + testConsent,
+		},
+		"several-vendors": {
+			template: "a=${GDPR_CONSENT_423}&b=${GDPR_CONSENT_1}&c=${GDPR_CONSENT_423}",
+			params:   Params{GDPRApplies: &applies, ConsentString: testConsent, Consent: consent},
+			want:     "a=" + testConsent + "&b=&c=" + testConsent,
+		},
+		"unknown-macros": {
+			template: "a=${UNKNOWN}&b=${GDPR_CONSENT_}&c=${GDPR_CONSENT_x}&d=${GDPR_CONSENT_0}",
+			params:   Params{GDPRApplies: &applies},
+			want:     "a=${UNKNOWN}&b=${GDPR_CONSENT_}&c=${GDPR_CONSENT_x}&d=${GDPR_CONSENT_0}",
+		},
+		"unterminated-macro": {
+			template: "gdpr=${GDPR}&gdpr_consent=${GDPR_CONSENT_423",
+			params:   Params{GDPRApplies: &applies},
+			want:     "gdpr=1&gdpr_consent=${GDPR_CONSENT_423",
+		},
+		"escaped-addtl-consent": {
+			template: "addtl_consent=${ADDTL_CONSENT}",
+			params:   Params{AddtlConsent: "2~1 2~dv.&"},
+			want:     "addtl_consent=2~1+2~dv.%26",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, Expand(tc.template, tc.params))
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package iabtcf
+
+import (
+	"encoding/base64"
+	"errors"
+	"expvar"
+	"strconv"
+	"sync/atomic"
+)
+
+// //////////////////////////////////////////////////
+// observer
+
+// Parser identifies the parser which emitted a ParseEvent
+type Parser string
+
+const (
+	EagerParser Parser = "eager"
+	LazyParser  Parser = "lazy"
+)
+
+// FailureReason classifies parse failures, it's empty on success
+//
+// note: the error messages are unchanged, the reason is derived from the error
+type FailureReason string
+
+const (
+	// ReasonEmpty is returned when the consent string is empty
+	ReasonEmpty FailureReason = "empty"
+	// ReasonDecode is returned when the core string is not valid base64
+	ReasonDecode FailureReason = "decode"
+	// ReasonTooShort is returned by the lazy parser when the core string is shorter than the fixed fields
+	ReasonTooShort FailureReason = "too_short"
+	// ReasonField is returned by the eager parser when a field of the core string can't be read
+	ReasonField FailureReason = "field"
+)
+
+// ParseEvent describes the outcome of a call to ParseCoreString or LazyParseCoreString
+type ParseEvent struct {
+	Parser Parser
+	// Length is the length of the consent string
+	Length int
+	// Err is the parse error, nil on success
+	Err    error
+	Reason FailureReason
+	// CMPID and IsRangeEncoding are only set on success
+	CMPID           int
+	IsRangeEncoding bool
+}
+
+// Observer is notified of every parse, it can be used to feed a metrics pipeline
+//
+// note: ObserveParse is called synchronously by the parsers, so it must be fast and safe for concurrent use
+type Observer interface {
+	ObserveParse(e ParseEvent)
+}
+
+type observerHolder struct {
+	o Observer
+}
+
+var observer atomic.Pointer[observerHolder]
+
+// SetObserver sets the Observer notified by the parsers, nil disables it ( default )
+func SetObserver(o Observer) {
+	if o == nil {
+		observer.Store(nil)
+		return
+	}
+	observer.Store(&observerHolder{o})
+}
+
+// loadObserver returns the observer, nil if none
+func loadObserver() Observer {
+	if h := observer.Load(); h != nil {
+		return h.o
+	}
+	return nil
+}
+
+// newParseEvent returns the event of a parse, the fields of the consent being set by the caller on success
+func newParseEvent(parser Parser, c string, err error) ParseEvent {
+	e := ParseEvent{Parser: parser, Length: len(c), Err: err}
+	if err != nil {
+		e.Reason = failureReason(c, err)
+	}
+	return e
+}
+
+var errTooShort = errors.New("consent string is too short")
+
+// failureReason classifies a parse error
+func failureReason(c string, err error) FailureReason {
+	var decodeErr base64.CorruptInputError
+	switch {
+	case c == "":
+		return ReasonEmpty
+	case errors.As(err, &decodeErr):
+		return ReasonDecode
+	case errors.Is(err, errTooShort):
+		return ReasonTooShort
+	default:
+		return ReasonField
+	}
+}
+
+// //////////////////////////////////////////////////
+// expvar observer
+
+// lengthBuckets are the upper bounds of the string length histogram
+var lengthBuckets = []int{64, 128, 256, 512, 1024, 2048, 4096}
+
+// ExpvarObserver is an Observer publishing counters with expvar
+//
+// The published map holds:
+//   - results: number of parses by parser and outcome, e.g. "lazy.success" or "eager.failure.decode"
+//   - cmp_ids: number of successful parses by CMP ID
+//   - encodings: number of successful parses by vendor consent encoding, "range" or "bitfield"
+//   - lengths: histogram of the consent string lengths, by upper bound ( "le_64", ..., "inf" )
+type ExpvarObserver struct {
+	results   *expvar.Map
+	cmpIDs    *expvar.Map
+	encodings *expvar.Map
+	lengths   *expvar.Map
+}
+
+// NewExpvarObserver returns an ExpvarObserver published under name
+//
+// note: as expvar.Publish, it panics if name is already published
+func NewExpvarObserver(name string) *ExpvarObserver {
+	o := &ExpvarObserver{
+		results:   new(expvar.Map),
+		cmpIDs:    new(expvar.Map),
+		encodings: new(expvar.Map),
+		lengths:   new(expvar.Map),
+	}
+	root := expvar.NewMap(name)
+	root.Set("results", o.results)
+	root.Set("cmp_ids", o.cmpIDs)
+	root.Set("encodings", o.encodings)
+	root.Set("lengths", o.lengths)
+	return o
+}
+
+// ObserveParse implements Observer
+func (o *ExpvarObserver) ObserveParse(e ParseEvent) {
+	o.lengths.Add(lengthBucket(e.Length), 1)
+	if e.Err != nil {
+		o.results.Add(string(e.Parser)+".failure."+string(e.Reason), 1)
+		return
+	}
+	o.results.Add(string(e.Parser)+".success", 1)
+	o.cmpIDs.Add(strconv.Itoa(e.CMPID), 1)
+	if e.IsRangeEncoding {
+		o.encodings.Add("range", 1)
+	} else {
+		o.encodings.Add("bitfield", 1)
+	}
+}
+
+func lengthBucket(length int) string {
+	for _, bound := range lengthBuckets {
+		if length <= bound {
+			return "le_" + strconv.Itoa(bound)
+		}
+	}
+	return "inf"
+}
@@ -0,0 +1,130 @@
+package iabtcf
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []ParseEvent
+}
+
+func (o *recordingObserver) ObserveParse(e ParseEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, e)
+}
+
+func TestObserver(t *testing.T) {
+
+	const validString = "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA"
+
+	type TestCase struct {
+		consent         string
+		lazy            bool
+		wantReason      FailureReason
+		wantCMPID       int
+		wantRangeEncode bool
+	}
+
+	testCases := map[string]*TestCase{
+		"eager-success": {
+			consent:         validString,
+			wantCMPID:       92,
+			wantRangeEncode: true,
+		},
+		"lazy-success": {
+			consent:         validString,
+			lazy:            true,
+			wantCMPID:       92,
+			wantRangeEncode: true,
+		},
+		"eager-empty": {
+			consent:    "",
+			wantReason: ReasonEmpty,
+		},
+		"lazy-empty": {
+			consent:    "",
+			lazy:       true,
+			wantReason: ReasonEmpty,
+		},
+		"eager-decode": {
+			consent:    "A",
+			wantReason: ReasonDecode,
+		},
+		"lazy-decode": {
+			consent:    "A",
+			lazy:       true,
+			wantReason: ReasonDecode,
+		},
+		"lazy-too-short": {
+			consent:    "COzcJxTOzcJx",
+			lazy:       true,
+			wantReason: ReasonTooShort,
+		},
+		"eager-field": {
+			consent:    "COzcJxTOzcJx",
+			wantReason: ReasonField,
+		},
+	}
+
+	o := &recordingObserver{}
+	SetObserver(o)
+	defer SetObserver(nil)
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			o.events = nil
+			var err error
+			if tc.lazy {
+				_, err = LazyParseCoreString(tc.consent)
+			} else {
+				_, err = ParseCoreString(tc.consent)
+			}
+
+			require.Len(t, o.events, 1)
+			e := o.events[0]
+			wantParser := EagerParser
+			if tc.lazy {
+				wantParser = LazyParser
+			}
+			require.Equal(t, wantParser, e.Parser)
+			require.Equal(t, len(tc.consent), e.Length)
+			require.Equal(t, err, e.Err)
+			require.Equal(t, tc.wantReason, e.Reason)
+			require.Equal(t, tc.wantCMPID, e.CMPID)
+			require.Equal(t, tc.wantRangeEncode, e.IsRangeEncoding)
+		})
+	}
+}
+
+func TestObserverDisabled(t *testing.T) {
+	o := &recordingObserver{}
+	SetObserver(o)
+	SetObserver(nil)
+
+	_, _ = ParseCoreString("A")
+	require.Empty(t, o.events)
+}
+
+func TestExpvarObserver(t *testing.T) {
+
+	o := NewExpvarObserver("iabtcf_test")
+	o.ObserveParse(ParseEvent{Parser: LazyParser, Length: 48, CMPID: 92})
+	o.ObserveParse(ParseEvent{Parser: LazyParser, Length: 100, CMPID: 92, IsRangeEncoding: true})
+	o.ObserveParse(ParseEvent{Parser: EagerParser, Length: 5000, Err: errTooShort, Reason: ReasonTooShort})
+
+	var got map[string]map[string]int
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("iabtcf_test").String()), &got))
+	require.Equal(t, map[string]map[string]int{
+		"results":   {"lazy.success": 2, "eager.failure.too_short": 1},
+		"cmp_ids":   {"92": 2},
+		"encodings": {"bitfield": 1, "range": 1},
+		"lengths":   {"le_64": 1, "le_128": 1, "inf": 1},
+	}, got)
+}
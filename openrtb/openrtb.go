@@ -0,0 +1,157 @@
+// Package openrtb extracts the consent fields of an OpenRTB 2.x bid request
+//
+// Only the fields related to consent are decoded, the rest of the bid request is skipped.
+// The following locations are supported:
+//   - user.consent ( 2.6 ), with a fallback on user.ext.consent ( 2.5 )
+//   - regs.gdpr ( 2.6 ), with a fallback on regs.ext.gdpr ( 2.5 )
+//   - regs.us_privacy ( 2.6 ), with a fallback on regs.ext.us_privacy ( 2.5 )
+//   - user.ext.ConsentedProvidersSettings.consented_providers, either as an additional consent string or as a list of IDs
+package openrtb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/travelaudience/go-iabtcf"
+	"github.com/travelaudience/go-iabtcf/acstring"
+	"github.com/travelaudience/go-iabtcf/usprivacy"
+)
+
+// Result is the consent extracted from a bid request
+type Result struct {
+	// GDPRApplies is nil when the gdpr field is absent or invalid
+	GDPRApplies *bool
+	// ConsentString is the TC String, empty when absent
+	ConsentString string
+	// Consent is the lazily parsed TC String, nil when absent or invalid
+	Consent *iabtcf.LazyConsent
+	// AddtlConsent is set when consented_providers is a string ( Google Additional Consent string )
+	AddtlConsent string
+	// AdditionalConsent is the parsed AddtlConsent, nil when absent or invalid
+	AdditionalConsent *acstring.AddtlConsent
+	// ConsentedProviders is set when consented_providers is a list of IDs
+	ConsentedProviders []int
+	// USPrivacyString is the US Privacy string, empty when absent
+	USPrivacyString string
+	// USPrivacy is the parsed US Privacy string, nil when absent or invalid
+	USPrivacy *usprivacy.USPrivacy
+}
+
+type bidRequest struct {
+	User *struct {
+		Consent *string `json:"consent"`
+		Ext     *struct {
+			Consent                    *string `json:"consent"`
+			ConsentedProvidersSettings *struct {
+				ConsentedProviders json.RawMessage `json:"consented_providers"`
+			} `json:"ConsentedProvidersSettings"`
+		} `json:"ext"`
+	} `json:"user"`
+	Regs *struct {
+		GDPR      json.RawMessage `json:"gdpr"`
+		USPrivacy *string         `json:"us_privacy"`
+		Ext       *struct {
+			GDPR      json.RawMessage `json:"gdpr"`
+			USPrivacy *string         `json:"us_privacy"`
+		} `json:"ext"`
+	} `json:"regs"`
+}
+
+// Extract extracts the consent fields of a raw bid request, and parses the TC String, the AC string and the US Privacy string
+//
+// note: if one of them can't be parsed, the other fields are still returned along with the error
+func Extract(data []byte) (*Result, error) {
+	var req bidRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("bid request decode failed: %w", err)
+	}
+
+	res := &Result{}
+	if req.Regs != nil {
+		res.GDPRApplies = parseGDPR(req.Regs.GDPR)
+		if res.GDPRApplies == nil && req.Regs.Ext != nil {
+			res.GDPRApplies = parseGDPR(req.Regs.Ext.GDPR)
+		}
+		if req.Regs.USPrivacy != nil {
+			res.USPrivacyString = *req.Regs.USPrivacy
+		}
+		if res.USPrivacyString == "" && req.Regs.Ext != nil && req.Regs.Ext.USPrivacy != nil {
+			res.USPrivacyString = *req.Regs.Ext.USPrivacy
+		}
+	}
+
+	if req.User != nil {
+		if req.User.Consent != nil {
+			res.ConsentString = *req.User.Consent
+		}
+		if ext := req.User.Ext; ext != nil {
+			if res.ConsentString == "" && ext.Consent != nil {
+				res.ConsentString = *ext.Consent
+			}
+			if ext.ConsentedProvidersSettings != nil {
+				raw := ext.ConsentedProvidersSettings.ConsentedProviders
+				if json.Unmarshal(raw, &res.AddtlConsent) != nil {
+					_ = json.Unmarshal(raw, &res.ConsentedProviders)
+				}
+			}
+		}
+	}
+
+	var errs []error
+	if res.ConsentString != "" {
+		if c, err := iabtcf.LazyParseCoreString(res.ConsentString); err != nil {
+			errs = append(errs, fmt.Errorf("consent parse failed: %w", err))
+		} else {
+			res.Consent = c
+		}
+	}
+	if res.AddtlConsent != "" {
+		if ac, err := acstring.Parse(res.AddtlConsent); err != nil {
+			errs = append(errs, fmt.Errorf("addtl consent parse failed: %w", err))
+		} else {
+			res.AdditionalConsent = ac
+		}
+	}
+	if res.USPrivacyString != "" {
+		if u, err := usprivacy.Parse(res.USPrivacyString); err != nil {
+			errs = append(errs, fmt.Errorf("us privacy parse failed: %w", err))
+		} else {
+			res.USPrivacy = u
+		}
+	}
+	return res, errors.Join(errs...)
+}
+
+// parseGDPR parses a gdpr field, which is expected to be 0 or 1
+//
+// note: some exchanges send it as a string or as a boolean, which are accepted too
+func parseGDPR(raw json.RawMessage) *bool {
+	if len(raw) == 0 {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil
+	}
+	var applies bool
+	switch v := value.(type) {
+	case float64:
+		if v != 0 && v != 1 {
+			return nil
+		}
+		applies = v == 1
+	case string:
+		n, err := strconv.Atoi(v)
+		if err != nil || (n != 0 && n != 1) {
+			return nil
+		}
+		applies = n == 1
+	case bool:
+		applies = v
+	default:
+		return nil
+	}
+	return &applies
+}
@@ -0,0 +1,122 @@
+package openrtb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testConsent = "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA"
+
+func TestExtract(t *testing.T) {
+
+	applies, notApplies := true, false
+
+	type TestCase struct {
+		request                string
+		wantErr                string
+		wantGDPRApplies        *bool
+		wantConsentString      string
+		wantAddtlConsent       string
+		wantConsentedProviders []int
+		wantUSPrivacyString    string
+	}
+
+	testCases := map[string]*TestCase{
+		"empty": {
+			request: `{"id":"1","imp":[{"id":"1"}]}`,
+		},
+		"invalid-json": {
+			request: `{"id":`,
+			wantErr: "bid request decode failed: unexpected end of JSON input",
+		},
+		"openrtb-2.5": {
+			request:           `{"id":"1","regs":{"ext":{"gdpr":1}},"user":{"id":"u","ext":{"consent":"` + testConsent + `"}}}`,
+			wantGDPRApplies:   &applies,
+			wantConsentString: testConsent,
+		},
+		"openrtb-2.6": {
+			request:           `{"id":"1","regs":{"gdpr":0},"user":{"consent":"` + testConsent + `"}}`,
+			wantGDPRApplies:   &notApplies,
+			wantConsentString: testConsent,
+		},
+		"2.6-takes-precedence": {
+			request:           `{"regs":{"gdpr":1,"ext":{"gdpr":0}},"user":{"consent":"` + testConsent + `","ext":{"consent":"ignored"}}}`,
+			wantGDPRApplies:   &applies,
+			wantConsentString: testConsent,
+		},
+		"gdpr-as-string": {
+			request:         `{"regs":{"ext":{"gdpr":"1"}}}`,
+			wantGDPRApplies: &applies,
+		},
+		"gdpr-invalid": {
+			request: `{"regs":{"gdpr":2}}`,
+		},
+		"addtl-consent-string": {
+			request:          `{"user":{"ext":{"ConsentedProvidersSettings":{"consented_providers":"1~1.35.41.101"}}}}`,
+			wantAddtlConsent: "1~1.35.41.101",
+		},
+		"addtl-consent-parse-error": {
+			request:          `{"user":{"ext":{"ConsentedProvidersSettings":{"consented_providers":"2~1.x"}}}}`,
+			wantErr:          `addtl consent parse failed: consented providers parse failed: invalid id "x"`,
+			wantAddtlConsent: "2~1.x",
+		},
+		"consented-providers-ids": {
+			request:                `{"user":{"ext":{"ConsentedProvidersSettings":{"consented_providers":[1,35,41]}}}}`,
+			wantConsentedProviders: []int{1, 35, 41},
+		},
+		"consent-parse-error": {
+			request:           `{"regs":{"gdpr":1},"user":{"consent":"A"}}`,
+			wantErr:           "consent parse failed: decode failed: illegal base64 data at input byte 0",
+			wantGDPRApplies:   &applies,
+			wantConsentString: "A",
+		},
+		"us-privacy-2.5": {
+			request:             `{"regs":{"ext":{"us_privacy":"1YNN"}}}`,
+			wantUSPrivacyString: "1YNN",
+		},
+		"us-privacy-2.6": {
+			request:             `{"regs":{"us_privacy":"1YYN","ext":{"us_privacy":"ignored"}}}`,
+			wantUSPrivacyString: "1YYN",
+		},
+		"us-privacy-parse-error": {
+			request:             `{"regs":{"gdpr":1,"us_privacy":"1Y"},"user":{"consent":"` + testConsent + `"}}`,
+			wantErr:             "us privacy parse failed: us privacy string must be 4 characters, got 2",
+			wantGDPRApplies:     &applies,
+			wantConsentString:   testConsent,
+			wantUSPrivacyString: "1Y",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Extract([]byte(tc.request))
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			if got == nil {
+				return
+			}
+
+			require.Equal(t, tc.wantGDPRApplies, got.GDPRApplies)
+			require.Equal(t, tc.wantConsentString, got.ConsentString)
+			require.Equal(t, tc.wantAddtlConsent, got.AddtlConsent)
+			require.Equal(t, tc.wantConsentedProviders, got.ConsentedProviders)
+			require.Equal(t, tc.wantUSPrivacyString, got.USPrivacyString)
+			if tc.wantConsentString == testConsent {
+				require.NotNil(t, got.Consent)
+				require.True(t, got.Consent.VendorAllowed(423))
+			}
+			if tc.wantErr == "" && tc.wantAddtlConsent != "" {
+				require.NotNil(t, got.AdditionalConsent)
+				require.True(t, got.AdditionalConsent.ProviderAllowed(35))
+			}
+			if tc.wantErr == "" && tc.wantUSPrivacyString != "" {
+				require.NotNil(t, got.USPrivacy)
+				require.Equal(t, tc.wantUSPrivacyString, got.USPrivacy.String())
+			}
+		})
+	}
+}
@@ -11,12 +11,29 @@ import (
 // note: the consent string is base64 decoded.
 // Then each field is parsed and stored in a Consent object.
 // This parser is optimized for checking multiple vendors + most of the fields.
+//
+// note: the sections following the vendor consents are optional, a missing one is left empty.
+// The segments which can't be decoded and the unknown ones are skipped.
+//
+// note: the Observer set by SetObserver, if any, is notified of the outcome.
 func ParseCoreString(c string) (*Consent, error) {
+	p, err := parseCoreString(c)
+	if o := loadObserver(); o != nil {
+		e := newParseEvent(EagerParser, c, err)
+		if err == nil {
+			e.CMPID, e.IsRangeEncoding = p.CMPID, p.IsRangeEncoding
+		}
+		o.ObserveParse(e)
+	}
+	return p, err
+}
+
+func parseCoreString(c string) (*Consent, error) {
 	if c == "" {
 		return nil, fmt.Errorf("consent string is empty")
 	}
 	// extract core string
-	cs, _, _ := strings.Cut(c, ".")
+	cs, segments, _ := strings.Cut(c, ".")
 
 	var b, err = base64.RawURLEncoding.DecodeString(cs)
 	if err != nil {
@@ -107,6 +124,7 @@ func ParseCoreString(c string) (*Consent, error) {
 		if err != nil {
 			return nil, fmt.Errorf("range entries parse failed: %w", err)
 		}
+		p.normalized = newNormalizedEntries(p.RangeEntries)
 	} else {
 		p.ConsentedVendors, err = r.ReadBitField(p.MaxVendorID)
 		if err != nil {
@@ -114,5 +132,149 @@ func ParseCoreString(c string) (*Consent, error) {
 		}
 	}
 
+	// note: strings ending after the vendor consents are accepted, the sections after them being read
+	// as the lazy parser reads them, and the publisher restrictions which can't be read being left empty
+	p.VendorLegitimateInterests = readVendorSection(r)
+	if p.PublisherRestrictions, err = r.ReadPublisherRestrictions(); err != nil {
+		p.PublisherRestrictions = nil
+	}
+
+	// Parse disclosed vendors, allowed vendors and publisher TC segments.  There are an arbitrary number of these
+	// segments in any order, and each segment type needs to be read to see what it is.
+	parseSegments(p, segments)
+
 	return p, nil
 }
+
+// parseSegments parses the optional segments and stores them in the Consent object
+//
+// note: segments which can't be decoded and unknown segment types are ignored. As LazyConsent does,
+// the segments of a repeated segment type are merged. They are merged once all of them are read,
+// so the work is bounded by the size of the consent string, whatever the number of segments.
+func parseSegments(p *Consent, segments string) {
+	var disclosed, allowed []*VendorSection
+	for segments != "" {
+		var segment string
+		segment, segments, _ = strings.Cut(segments, ".")
+		b, err := base64.RawURLEncoding.DecodeString(segment)
+		if err != nil {
+			continue
+		}
+
+		r := NewReader(b)
+		segmentType, err := r.ReadInt(3)
+		if err != nil {
+			continue
+		}
+
+		switch segmentType {
+		case disclosedVendorsSegmentType:
+			s := readVendorSection(r)
+			disclosed = append(disclosed, &s)
+		case allowedVendorsSegmentType:
+			s := readVendorSection(r)
+			allowed = append(allowed, &s)
+		case publisherTCSegmentType:
+			if tc, err := r.ReadPublisherTC(); err == nil {
+				p.PublisherTC = unionPublisherTCs(p.PublisherTC, tc)
+			}
+		}
+	}
+	p.DisclosedVendorsSegment = unionVendorSections(disclosed)
+	p.AllowedVendorsSegment = unionVendorSections(allowed)
+}
+
+// unionVendorSections returns the union of the sections, nil if there's none, the section itself if there's one
+//
+// note: the union is bitfield encoded, the IDs of each section being bounded by its max vendor ID.
+// The range entries of all the sections are normalized together, so each bit is set once.
+func unionVendorSections(sections []*VendorSection) *VendorSection {
+	switch len(sections) {
+	case 0:
+		return nil
+	case 1:
+		return sections[0]
+	}
+
+	union := &VendorSection{}
+	for _, s := range sections {
+		union.MaxVendorID = max(union.MaxVendorID, s.MaxVendorID)
+	}
+	union.BitField = make(Bits, (union.MaxVendorID+lastBitIndex)/nbBitInByte)
+
+	var entries []RangeEntry
+	for _, s := range sections {
+		if !s.IsRangeEncoding {
+			// note: the bits after the max vendor ID are not part of the bit field, they are zeros
+			for i, b := range s.BitField {
+				union.BitField[i] |= b
+			}
+			continue
+		}
+		for _, e := range s.RangeEntries {
+			if start, end := max(e.StartOrOnlyVendorId, 1), min(e.EndVendorID, s.MaxVendorID); start <= end {
+				entries = append(entries, RangeEntry{StartOrOnlyVendorId: start, EndVendorID: end})
+			}
+		}
+	}
+	for _, e := range NormalizeRangeEntries(entries) {
+		union.BitField.setBits(e.StartOrOnlyVendorId, e.EndVendorID)
+	}
+	return union
+}
+
+// setBits sets the bit numbers from start to end, a whole byte at a time when possible
+func (b Bits) setBits(start, end int) {
+	for ; start <= end && (start-1)%nbBitInByte != 0; start++ {
+		b.set(start)
+	}
+	for ; start+lastBitIndex <= end; start += nbBitInByte {
+		b[(start-1)/nbBitInByte] = 0xff
+	}
+	for ; start <= end; start++ {
+		b.set(start)
+	}
+}
+
+// unionPublisherTCs returns the union of the publisher TC segments, tc itself if there's no previous segment
+func unionPublisherTCs(previous, tc *PublisherTC) *PublisherTC {
+	if previous == nil {
+		return tc
+	}
+	return &PublisherTC{
+		PubPurposesConsent:           previous.PubPurposesConsent.Or(tc.PubPurposesConsent),
+		PubPurposesLITransparency:    previous.PubPurposesLITransparency.Or(tc.PubPurposesLITransparency),
+		NumCustomPurposes:            max(previous.NumCustomPurposes, tc.NumCustomPurposes),
+		CustomPurposesConsent:        previous.CustomPurposesConsent.Or(tc.CustomPurposesConsent),
+		CustomPurposesLITransparency: previous.CustomPurposesLITransparency.Or(tc.CustomPurposesLITransparency),
+	}
+}
+
+// readVendorSection reads the vendor section at the offset of r
+//
+// note: when the section is truncated, it's read as the lazy parser reads it: the missing bits are zeros,
+// and the range entries starting beyond the end are skipped. So a missing section is empty.
+func readVendorSection(r *Reader) VendorSection {
+	offset := r.Offset()
+	if s, err := r.ReadVendorSection(); err == nil {
+		return s
+	}
+	_ = r.Seek(r.bits.Length())
+
+	s := VendorSection{MaxVendorID: r.bits.ReadIntField(offset, 16), IsRangeEncoding: r.bits.ReadBoolField(offset + 16)}
+	if s.IsRangeEncoding {
+		s.NumEntries = r.bits.ReadIntField(offset+17, 12)
+		s.RangeEntries = r.bits.readRangeEntries(offset + 17)
+		s.normalized = newNormalizedEntries(s.RangeEntries)
+		return s
+	}
+	s.BitField = make(Bits, (s.MaxVendorID+lastBitIndex)/nbBitInByte)
+	for i := range s.BitField {
+		s.BitField[i] = byte(r.bits.ReadInt64Field(offset+17+i*nbBitInByte, nbBitInByte))
+	}
+	if remaining := s.MaxVendorID % nbBitInByte; remaining > 0 {
+		// note: the bits after the max vendor ID are not part of the bit field
+		s.BitField[len(s.BitField)-1] &= byte(0xff << (nbBitInByte - remaining))
+	}
+	return s
+}
@@ -1,6 +1,9 @@
 package iabtcf
 
 import (
+	"fmt"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -340,6 +343,82 @@ func TestParseCoreString(t *testing.T) {
 			nil,
 			true,
 		},
+		{
+			"duplicated-segment",
+			"COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA.IAFP_A.IACsgA",
+			&Consent{
+				Version:                2,
+				CMPID:                  92,
+				ConsentLanguage:        "EN",
+				VendorListVersion:      34,
+				TcfPolicyVersion:       2,
+				SpecialFeatureOptIns:   Bits{0xc0, 0x0},
+				PurposesConsent:        Bits{0xff, 0xc0, 0x0},
+				PurposesLITransparency: Bits{0x0, 0x0, 0x0},
+				PublisherCC:            "AA",
+				MaxVendorID:            423,
+				NumEntries:             1,
+				IsRangeEncoding:        true,
+				RangeEntries:           []RangeEntry{{StartOrOnlyVendorId: 423, EndVendorID: 423}},
+				VendorLegitimateInterests: VendorSection{
+					BitField: Bits{},
+				},
+				PublisherRestrictions: []PublisherRestriction{},
+				// note: the duplicated disclosed vendors segments are merged
+				DisclosedVendorsSegment: &VendorSection{
+					MaxVendorID: 10,
+					BitField:    Bits{0xff, 0xc0},
+				},
+			},
+			false,
+		},
+		{
+			"truncated-after-vendor-consents",
+			"COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTg",
+			&Consent{
+				Version:                2,
+				CMPID:                  92,
+				ConsentLanguage:        "EN",
+				VendorListVersion:      34,
+				TcfPolicyVersion:       2,
+				SpecialFeatureOptIns:   Bits{0xc0, 0x0},
+				PurposesConsent:        Bits{0xff, 0xc0, 0x0},
+				PurposesLITransparency: Bits{0x0, 0x0, 0x0},
+				PublisherCC:            "AA",
+				MaxVendorID:            423,
+				NumEntries:             1,
+				IsRangeEncoding:        true,
+				RangeEntries:           []RangeEntry{{StartOrOnlyVendorId: 423, EndVendorID: 423}},
+				VendorLegitimateInterests: VendorSection{
+					BitField: Bits{},
+				},
+			},
+			false,
+		},
+		{
+			"undecodable-and-unknown-segments",
+			"COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA.I!.gA.",
+			&Consent{
+				Version:                2,
+				CMPID:                  92,
+				ConsentLanguage:        "EN",
+				VendorListVersion:      34,
+				TcfPolicyVersion:       2,
+				SpecialFeatureOptIns:   Bits{0xc0, 0x0},
+				PurposesConsent:        Bits{0xff, 0xc0, 0x0},
+				PurposesLITransparency: Bits{0x0, 0x0, 0x0},
+				PublisherCC:            "AA",
+				MaxVendorID:            423,
+				NumEntries:             1,
+				IsRangeEncoding:        true,
+				RangeEntries:           []RangeEntry{{StartOrOnlyVendorId: 423, EndVendorID: 423}},
+				VendorLegitimateInterests: VendorSection{
+					BitField: Bits{},
+				},
+				PublisherRestrictions: []PublisherRestriction{},
+			},
+			false,
+		},
 		{
 			"with-values",
 			"COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA.IF5EX2S5OI2tho2YdF7BEYYwfJxyigMgShgQIsS8NwIeFbBoGPmAAHBG4JAQAGBAkkACBAQIsHGBcCQABgIgRiRCMQEGMjzNKBJBAggkbI0FACCVmnkHS3ZCY70-6u__bA",
@@ -357,6 +436,21 @@ func TestParseCoreString(t *testing.T) {
 				NumEntries:             1,
 				IsRangeEncoding:        true,
 				RangeEntries:           []RangeEntry{{StartOrOnlyVendorId: 423, EndVendorID: 423}},
+				VendorLegitimateInterests: VendorSection{
+					BitField: Bits{},
+				},
+				PublisherRestrictions: []PublisherRestriction{},
+				DisclosedVendorsSegment: &VendorSection{
+					MaxVendorID: 754,
+					BitField: Bits{
+						0x45, 0xf6, 0x4b, 0x93, 0x88, 0xda, 0xd8, 0x68, 0xd9, 0x87, 0x45, 0xec, 0x11, 0x18, 0x63, 0x7,
+						0xc9, 0xc7, 0x28, 0xa0, 0x32, 0x4, 0xa1, 0x81, 0x2, 0x2c, 0x4b, 0xc3, 0x70, 0x21, 0xe1, 0x5b,
+						0x6, 0x81, 0x8f, 0x98, 0x0, 0x7, 0x4, 0x6e, 0x9, 0x1, 0x0, 0x6, 0x4, 0x9, 0x24, 0x0,
+						0x20, 0x40, 0x40, 0x8b, 0x7, 0x18, 0x17, 0x2, 0x40, 0x0, 0x60, 0x22, 0x4, 0x62, 0x44, 0x23,
+						0x10, 0x10, 0x63, 0x23, 0xcc, 0xd2, 0x81, 0x24, 0x10, 0x20, 0x82, 0x46, 0xc8, 0xd0, 0x50, 0x2,
+						0x9, 0x59, 0xa7, 0x90, 0x74, 0xb7, 0x64, 0x26, 0x3b, 0xd3, 0xee, 0xae, 0xff, 0xf6, 0xc0,
+					},
+				},
 			},
 			false,
 		},
@@ -381,7 +475,181 @@ func TestParseCoreString(t *testing.T) {
 
 			got.Created = time.Time{}
 			got.LastUpdated = time.Time{}
+			// note: the parser keeps a normalized copy of the range entries for the lookups
+			tt.want.normalized = newNormalizedEntries(tt.want.RangeEntries)
 			require.Equal(t, tt.want, got, "wrong consent")
 		})
 	}
 }
+
+func TestNormalizeRangeEntries(t *testing.T) {
+
+	tests := map[string]struct {
+		entries []RangeEntry
+		want    []RangeEntry
+	}{
+		"empty": {
+			entries: []RangeEntry{},
+			want:    []RangeEntry{},
+		},
+		"sorted-disjoint": {
+			entries: []RangeEntry{{1, 1}, {3, 5}, {10, 12}},
+			want:    []RangeEntry{{1, 1}, {3, 5}, {10, 12}},
+		},
+		"unsorted": {
+			entries: []RangeEntry{{10, 12}, {1, 1}, {3, 5}},
+			want:    []RangeEntry{{1, 1}, {3, 5}, {10, 12}},
+		},
+		"overlapping-and-adjacent": {
+			entries: []RangeEntry{{3, 8}, {1, 2}, {5, 6}, {9, 9}, {20, 30}, {25, 40}},
+			want:    []RangeEntry{{1, 9}, {20, 40}},
+		},
+		"empty-entries": {
+			entries: []RangeEntry{{5, 4}, {2, 2}, {0, 0}},
+			want:    []RangeEntry{{0, 0}, {2, 2}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NormalizeRangeEntries(tc.entries)
+			require.Equal(t, tc.want, got)
+			normalized := newNormalizedEntries(got)
+			for number := 0; number <= 41; number++ {
+				want := false
+				for _, e := range tc.want {
+					want = want || (e.StartOrOnlyVendorId <= number && number <= e.EndVendorID)
+				}
+				require.Equal(t, want, rangeEntriesContain(got, &normalized, number), "number %d", number)
+			}
+		})
+	}
+}
+
+func TestVendorAllowedUnsortedRanges(t *testing.T) {
+
+	// note: entries are encoded unsorted and overlapping, as the spec doesn't guarantee any order
+	var entries []RangeEntry
+	for i := 50; i > 0; i-- {
+		entries = append(entries, RangeEntry{StartOrOnlyVendorId: 10 * i, EndVendorID: 10*i + 3})
+	}
+	entries = append(entries, RangeEntry{StartOrOnlyVendorId: 12, EndVendorID: 25}, RangeEntry{StartOrOnlyVendorId: 7, EndVendorID: 7})
+	c, err := EncodeCoreString(&Consent{
+		Version:         2,
+		ConsentLanguage: "EN",
+		PublisherCC:     "AA",
+		MaxVendorID:     503,
+		IsRangeEncoding: true,
+		RangeEntries:    entries,
+		VendorLegitimateInterests: VendorSection{
+			MaxVendorID:     30,
+			IsRangeEncoding: true,
+			RangeEntries:    []RangeEntry{{20, 30}, {1, 2}},
+		},
+	})
+	require.NoError(t, err, "unexpected encode error")
+
+	eager, err := ParseCoreString(c)
+	require.NoError(t, err, "unexpected parse error")
+	lazy, err := LazyParseCoreString(c)
+	require.NoError(t, err, "unexpected lazy parse error")
+
+	// note: the entries are kept as encoded
+	require.Equal(t, 52, eager.NumEntries, "the number of entries must be the encoded one")
+	require.Equal(t, entries, eager.RangeEntries, "range entries must be kept as encoded")
+	require.Equal(t, []RangeEntry{{20, 30}, {1, 2}}, eager.VendorLegitimateInterests.RangeEntries)
+
+	// note: the parsed entries are looked up in their normalized copy, the entries set by hand are scanned,
+	// and both must give the same result
+	sorted, ok := eager.normalized.of(eager.RangeEntries)
+	require.True(t, ok, "missing normalized copy of the parsed entries")
+	require.True(t, rangeEntriesNormalized(sorted))
+	normalized := eager.Clone()
+	normalized.RangeEntries = NormalizeRangeEntries(normalized.RangeEntries)
+	_, ok = normalized.normalized.of(normalized.RangeEntries)
+	require.False(t, ok, "the normalized copy must not be used for entries set by hand")
+	clone := eager.Clone()
+	_, ok = clone.normalized.of(clone.RangeEntries)
+	require.True(t, ok, "missing normalized copy of the cloned entries")
+
+	var ids []int
+	for number := 0; number <= 510; number++ {
+		want := false
+		for _, e := range entries {
+			want = want || (e.StartOrOnlyVendorId <= number && number <= e.EndVendorID)
+		}
+		require.Equal(t, want, eager.VendorAllowed(number), "eager vendor %d", number)
+		require.Equal(t, want, normalized.VendorAllowed(number), "normalized vendor %d", number)
+		require.Equal(t, want, lazy.VendorAllowed(number), "lazy vendor %d", number)
+		require.Equal(t, number == 1 || number == 2 || (number >= 20 && number <= 30), eager.VendorLIAllowed(number), "eager li vendor %d", number)
+		ids = append(ids, number)
+	}
+	require.Equal(t, normalized.FilterAllowed(ids), eager.FilterAllowed(ids))
+	require.Equal(t, lazy.FilterAllowed(ids), eager.FilterAllowed(ids))
+
+	allocs := testing.AllocsPerRun(100, func() { lazy.VendorAllowed(252) })
+	require.Zero(t, allocs, "lookups must not allocate once the index is built")
+
+	// reparsing into the same consent must drop the index
+	small := "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA"
+	require.NoError(t, LazyParseInto(lazy, small))
+	eager, err = ParseCoreString(small)
+	require.NoError(t, err, "unexpected parse error")
+	for _, number := range []int{7, 252, 423} {
+		require.Equal(t, eager.VendorAllowed(number), lazy.VendorAllowed(number), "vendor %d after reparse", number)
+	}
+}
+
+func TestParseDuplicatedSegments(t *testing.T) {
+
+	allowed := func(maxVendorID int, bitField string) string {
+		return encodeBits(sprintb(2, 3) + sprintb(maxVendorID, 16) + "0" + bitField)
+	}
+	publisherTC := encodeBits(sprintb(3, 3) +
+		"001000000000000000000000" + // pub purposes consent
+		"000000000000000000000000" + // pub purposes li transparency
+		sprintb(3, 6) + "001" + "100") // custom purposes
+
+	got, err := ParseCoreString(encodeBits(testCoreBits) + "." + allowed(5, "10011") + "." + encodeBits(testPublisherTCBits) + "." + allowed(8, "01000001") + "." + publisherTC)
+	require.NoError(t, err, "unexpected parse error")
+
+	require.Equal(t, []int{1, 2, 4, 5, 8}, got.AllowedVendorsSegment.ids(), "wrong allowed vendors")
+	require.Equal(t, 8, got.AllowedVendorsSegment.MaxVendorID, "wrong allowed max vendor ID")
+	require.Equal(t, []int{1, 3}, got.PublisherTC.PubPurposesConsent.ToIDs(), "wrong publisher purposes consent")
+	require.Equal(t, []int{2}, got.PublisherTC.PubPurposesLITransparency.ToIDs(), "wrong publisher purposes li transparency")
+	require.Equal(t, 3, got.PublisherTC.NumCustomPurposes, "wrong number of custom purposes")
+	require.Equal(t, []int{2, 3}, got.PublisherTC.CustomPurposesConsent.ToIDs(), "wrong custom purposes consent")
+	require.Equal(t, []int{1}, got.PublisherTC.CustomPurposesLITransparency.ToIDs(), "wrong custom purposes li transparency")
+}
+
+// fullRangeSegment is a disclosed vendors segment with a single range entry covering every vendor ID
+var fullRangeSegment = encodeBits(sprintb(1, 3) + sprintb(maxID, 16) + "1" + sprintb(1, 12) + "1" + sprintb(1, 16) + sprintb(maxID, 16))
+
+func TestParseDuplicatedSegmentsCost(t *testing.T) {
+
+	// note: merging the segments must cost their size, not the number of vendors they cover
+	c := "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA" + strings.Repeat("."+fullRangeSegment, 1000)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	got, err := ParseCoreString(c)
+	runtime.ReadMemStats(&after)
+	require.NoError(t, err, "unexpected parse error")
+	require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(64*len(c)), "too many bytes allocated for %d input bytes", len(c))
+
+	require.Equal(t, maxID, got.DisclosedVendorsSegment.MaxVendorID)
+	require.Equal(t, maxID, got.DisclosedVendorsSegment.BitField.Count())
+}
+
+func BenchmarkParseDuplicatedSegments(b *testing.B) {
+	for _, n := range []int{1, 100, 1000} {
+		c := "COzcJxTOzcJxTBcAAAENAiCMAP_AAAAAAAAADTwAQDTgAAAA" + strings.Repeat("."+fullRangeSegment, n)
+		b.Run(fmt.Sprintf("%d-segments", n), func(b *testing.B) {
+			b.SetBytes(int64(len(c)))
+			for b.Loop() {
+				if _, err := ParseCoreString(c); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
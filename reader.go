@@ -1,10 +1,9 @@
 package iabtcf
 
 import (
+	"errors"
 	"fmt"
 	"time"
-
-	"github.com/rupertchen/go-bits"
 )
 
 const (
@@ -14,20 +13,72 @@ const (
 	nsPerDs = int64(time.Millisecond * 100)
 )
 
+var (
+	// ErrOutOfRange is returned by the Reader when reading beyond the end of the bits
+	ErrOutOfRange = errors.New("bits: length extends beyond range")
+	// ErrTooManyBits is returned by the Reader when reading more than 64 bits at once
+	ErrTooManyBits = errors.New("bits: length exceeds 64 bits")
+)
+
+// Reader is a sequential cursor over Bits
+//
+// note: it reads the fields with the Bits field readers, and fails instead of reading trailing zeros when out of bound
 type Reader struct {
-	*bits.Reader
+	bits   Bits
+	offset int
 }
 
 // NewReader returns a new Reader
 func NewReader(src []byte) *Reader {
-	return &Reader{bits.NewReader(bits.NewBitmap(src))}
+	return &Reader{bits: Bits(src)}
+}
+
+// Offset returns the offset of the next bit to read
+func (r *Reader) Offset() int {
+	return r.offset
+}
+
+// Seek sets the offset of the next bit to read
+func (r *Reader) Seek(offset int) error {
+	if offset < 0 || offset > r.bits.Length() {
+		return fmt.Errorf("seek (index=%d): %w", offset, ErrOutOfRange)
+	}
+	r.offset = offset
+	return nil
+}
+
+// ReadBits reads the next n bits, the first bit read being the most significant one
+//
+// note: the offset is not moved on error
+func (r *Reader) ReadBits(n uint) (uint64, error) {
+	if n > nbBitInWord {
+		return 0, fmt.Errorf("read bits (index=%d, length=%d): %w", r.offset, n, ErrTooManyBits)
+	}
+	if r.offset+int(n) > r.bits.Length() {
+		return 0, fmt.Errorf("read bits (index=%d, length=%d): %w", r.offset, n, ErrOutOfRange)
+	}
+	v := uint64(r.bits.ReadInt64Field(r.offset, int(n)))
+	r.offset += int(n)
+	return v, nil
+}
+
+// ReadBool reads the next bit
+func (r *Reader) ReadBool() (bool, error) {
+	b, err := r.ReadBits(boolNbBits)
+	return b == 1, err
+}
+
+// ReadByte reads the next 8 bits
+func (r *Reader) ReadByte() (byte, error) {
+	b, err := r.ReadBits(nbBitInByte)
+	return byte(b), err
 }
 
 // ReadInt reads the next n bits and converts them to an int.
 func (r *Reader) ReadInt(n uint) (int, error) {
 	b, err := r.ReadBits(n)
 	if err != nil {
-		return 0, fmt.Errorf("ReadBits failed: %s", err.Error())
+		return 0, fmt.Errorf("ReadBits failed: %w", err)
 	}
 
 	return int(b), nil
@@ -38,7 +89,7 @@ func (r *Reader) ReadInt(n uint) (int, error) {
 func (r *Reader) ReadTime() (time.Time, error) {
 	b, err := r.ReadBits(36)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("ReadBits failed: %s", err.Error())
+		return time.Time{}, fmt.Errorf("ReadBits failed: %w", err)
 	}
 	ds := int64(b)
 	return time.Unix(ds/dsPerSec, (ds%dsPerSec)*nsPerDs).UTC(), nil
@@ -50,7 +101,7 @@ func (r *Reader) ReadString(length int) (string, error) {
 	var buf = make([]byte, 0, length)
 	for i := 0; i < length; i++ {
 		if b, err := r.ReadBits(6); err != nil {
-			return "", fmt.Errorf("ReadBits failed: %s", err.Error())
+			return "", fmt.Errorf("ReadBits failed: %w", err)
 		} else {
 			buf = append(buf, byte(b)+'A')
 		}
@@ -66,14 +117,14 @@ func (r *Reader) ReadBitField(length int) (Bits, error) {
 	for i := 0; i < nb; i++ {
 		b, err := r.ReadByte()
 		if err != nil {
-			return Bits{}, fmt.Errorf("ReadByte failed: %s", err.Error())
+			return Bits{}, fmt.Errorf("ReadByte failed: %w", err)
 		}
 		bytes = append(bytes, b)
 	}
 	if remaining > 0 {
 		block, err := r.ReadBits(uint(remaining))
 		if err != nil {
-			return Bits{}, fmt.Errorf("ReadBits failed: %s", err.Error())
+			return Bits{}, fmt.Errorf("ReadBits failed: %w", err)
 		}
 		// note: remaining bits are right aligned, while Bits is left aligned
 		b := byte(block << (8 - remaining))
@@ -89,15 +140,15 @@ func (r *Reader) ReadRangeEntries(length int) ([]RangeEntry, error) {
 	for i := 0; i < length; i++ {
 		var isRange bool
 		if isRange, err = r.ReadBool(); err != nil {
-			return nil, fmt.Errorf("ReadBool failed: %s", err.Error())
+			return nil, fmt.Errorf("ReadBool failed: %w", err)
 		}
 		var start, end int
 		if start, err = r.ReadInt(16); err != nil {
-			return nil, fmt.Errorf("ReadInt failed: %s", err.Error())
+			return nil, fmt.Errorf("ReadInt failed: %w", err)
 		}
 		if isRange {
 			if end, err = r.ReadInt(16); err != nil {
-				return nil, fmt.Errorf("ReadInt failed: %s", err.Error())
+				return nil, fmt.Errorf("ReadInt failed: %w", err)
 			}
 		} else {
 			end = start
@@ -106,3 +157,141 @@ func (r *Reader) ReadRangeEntries(length int) ([]RangeEntry, error) {
 	}
 	return res, nil
 }
+
+// ReadVendorSection reads a vendor section: max vendor id, encoding type, then
+// either a bitfield of max vendor id bits or a list of range entries
+func (r *Reader) ReadVendorSection() (VendorSection, error) {
+	var s VendorSection
+	var err error
+	if s.MaxVendorID, err = r.ReadInt(16); err != nil {
+		return VendorSection{}, fmt.Errorf("ReadInt failed: %w", err)
+	}
+	if s.IsRangeEncoding, err = r.ReadBool(); err != nil {
+		return VendorSection{}, fmt.Errorf("ReadBool failed: %w", err)
+	}
+	if s.IsRangeEncoding {
+		if s.NumEntries, err = r.ReadInt(12); err != nil {
+			return VendorSection{}, fmt.Errorf("ReadInt failed: %w", err)
+		}
+		if s.RangeEntries, err = r.ReadRangeEntries(s.NumEntries); err != nil {
+			return VendorSection{}, fmt.Errorf("ReadRangeEntries failed: %w", err)
+		}
+		s.normalized = newNormalizedEntries(s.RangeEntries)
+	} else {
+		if s.BitField, err = r.ReadBitField(s.MaxVendorID); err != nil {
+			return VendorSection{}, fmt.Errorf("ReadBitField failed: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// ReadPublisherRestrictions reads the number of publisher restrictions, then each restriction
+func (r *Reader) ReadPublisherRestrictions() ([]PublisherRestriction, error) {
+	length, err := r.ReadInt(12)
+	if err != nil {
+		return nil, fmt.Errorf("ReadInt failed: %w", err)
+	}
+	res := make([]PublisherRestriction, 0, length)
+	for i := 0; i < length; i++ {
+		var pr PublisherRestriction
+		if pr.PurposeID, err = r.ReadInt(6); err != nil {
+			return nil, fmt.Errorf("ReadInt failed: %w", err)
+		}
+		var restrictionType int
+		if restrictionType, err = r.ReadInt(2); err != nil {
+			return nil, fmt.Errorf("ReadInt failed: %w", err)
+		}
+		pr.RestrictionType = RestrictionType(restrictionType)
+		if pr.NumEntries, err = r.ReadInt(12); err != nil {
+			return nil, fmt.Errorf("ReadInt failed: %w", err)
+		}
+		if pr.RangeEntries, err = r.ReadRangeEntries(pr.NumEntries); err != nil {
+			return nil, fmt.Errorf("ReadRangeEntries failed: %w", err)
+		}
+		res = append(res, pr)
+	}
+	return res, nil
+}
+
+// ReadPublisherTC reads the publisher purposes transparency and consent fields
+//
+// note: the segment type is expected to be already read
+func (r *Reader) ReadPublisherTC() (*PublisherTC, error) {
+	var tc PublisherTC
+	var err error
+	if tc.PubPurposesConsent, err = r.ReadBitField(24); err != nil {
+		return nil, fmt.Errorf("ReadBitField failed: %w", err)
+	}
+	if tc.PubPurposesLITransparency, err = r.ReadBitField(24); err != nil {
+		return nil, fmt.Errorf("ReadBitField failed: %w", err)
+	}
+	if tc.NumCustomPurposes, err = r.ReadInt(6); err != nil {
+		return nil, fmt.Errorf("ReadInt failed: %w", err)
+	}
+	if tc.CustomPurposesConsent, err = r.ReadBitField(tc.NumCustomPurposes); err != nil {
+		return nil, fmt.Errorf("ReadBitField failed: %w", err)
+	}
+	if tc.CustomPurposesLITransparency, err = r.ReadBitField(tc.NumCustomPurposes); err != nil {
+		return nil, fmt.Errorf("ReadBitField failed: %w", err)
+	}
+	return &tc, nil
+}
+
+// maxFibonacciNbBits bounds the length of a Fibonacci encoded integer
+const maxFibonacciNbBits = 64
+
+// ReadFibonacciInt reads the next Fibonacci encoded integer ( terminated by two consecutive 1 bits )
+func (r *Reader) ReadFibonacciInt() (int, error) {
+	value := 0
+	fib, nextFib := 1, 2
+	previous := false
+	for i := 0; i < maxFibonacciNbBits; i++ {
+		b, err := r.ReadBool()
+		if err != nil {
+			return 0, fmt.Errorf("ReadBool failed: %w", err)
+		}
+		if b && previous {
+			return value, nil
+		}
+		if b {
+			value += fib
+		}
+		previous = b
+		fib, nextFib = nextFib, fib+nextFib
+	}
+	return 0, fmt.Errorf("fibonacci integer longer than %d bits", maxFibonacciNbBits)
+}
+
+// ReadFibonacciRange reads a 12 bits number of entries, then each entry as Fibonacci encoded offsets
+//
+// note: each entry is either a single ID, or a range defined by its start and its length,
+// and every offset is relative to the previous ID.
+func (r *Reader) ReadFibonacciRange() ([]RangeEntry, error) {
+	length, err := r.ReadInt(12)
+	if err != nil {
+		return nil, fmt.Errorf("ReadInt failed: %w", err)
+	}
+	res := make([]RangeEntry, 0, length)
+	last := 0
+	for i := 0; i < length; i++ {
+		var isRange bool
+		if isRange, err = r.ReadBool(); err != nil {
+			return nil, fmt.Errorf("ReadBool failed: %w", err)
+		}
+		var start, end int
+		if start, err = r.ReadFibonacciInt(); err != nil {
+			return nil, fmt.Errorf("ReadFibonacciInt failed: %w", err)
+		}
+		start += last
+		end = start
+		if isRange {
+			if end, err = r.ReadFibonacciInt(); err != nil {
+				return nil, fmt.Errorf("ReadFibonacciInt failed: %w", err)
+			}
+			end += start
+		}
+		last = end
+		res = append(res, RangeEntry{StartOrOnlyVendorId: start, EndVendorID: end})
+	}
+	return res, nil
+}
@@ -61,3 +61,93 @@ func TestReader(t *testing.T) {
 		})
 	}
 }
+
+func TestReaderFibonacci(t *testing.T) {
+
+	tests := []struct {
+		bits      string
+		wantInt   int
+		wantRange []RangeEntry
+		wantErr   bool
+	}{
+		{bits: "11", wantInt: 1},
+		{bits: "011", wantInt: 2},
+		{bits: "0011", wantInt: 3},
+		{bits: "1011", wantInt: 4},
+		{bits: "00011", wantInt: 5},
+		{bits: "10011", wantInt: 6},
+		{bits: "0000", wantErr: true},
+		// GPP header section ids of "DBABMA": 1 entry, section 2
+		{bits: "000000000001" + "0" + "011", wantRange: []RangeEntry{{StartOrOnlyVendorId: 2, EndVendorID: 2}}},
+		// 2 entries: 2, then range 2+1=3 to 3+2=5
+		{bits: "000000000010" + "0" + "011" + "1" + "11" + "011", wantRange: []RangeEntry{{2, 2}, {3, 5}}},
+	}
+
+	for i, tt := range tests {
+		r := NewReader(BitStringToBytes(tt.bits))
+		if tt.wantRange != nil {
+			got, err := r.ReadFibonacciRange()
+			require.NoError(t, err, "case %d", i)
+			require.Equal(t, tt.wantRange, got, "case %d", i)
+			continue
+		}
+		got, err := r.ReadFibonacciInt()
+		if tt.wantErr {
+			require.Error(t, err, "case %d", i)
+			continue
+		}
+		require.NoError(t, err, "case %d", i)
+		require.Equal(t, tt.wantInt, got, "case %d", i)
+	}
+}
+
+func TestReaderCursor(t *testing.T) {
+
+	r := NewReader([]byte{0b10110011, 0b01000000})
+	require.Equal(t, 0, r.Offset())
+
+	v, err := r.ReadBits(3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0b101), v)
+	require.Equal(t, 3, r.Offset())
+
+	b, err := r.ReadBool()
+	require.NoError(t, err)
+	require.True(t, b)
+
+	by, err := r.ReadByte()
+	require.NoError(t, err)
+	require.Equal(t, byte(0b00110100), by)
+	require.Equal(t, 12, r.Offset())
+
+	// reading beyond the end fails without moving the offset
+	_, err = r.ReadBits(5)
+	require.EqualError(t, err, "read bits (index=12, length=5): bits: length extends beyond range")
+	require.ErrorIs(t, err, ErrOutOfRange)
+	require.Equal(t, 12, r.Offset())
+
+	v, err = r.ReadBits(4)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), v)
+
+	_, err = r.ReadBits(65)
+	require.ErrorIs(t, err, ErrTooManyBits)
+
+	require.NoError(t, r.Seek(1))
+	v, err = r.ReadBits(2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0b01), v)
+
+	require.NoError(t, r.Seek(16))
+	require.EqualError(t, r.Seek(17), "seek (index=17): bits: length extends beyond range")
+	require.ErrorIs(t, r.Seek(-1), ErrOutOfRange)
+	require.Equal(t, 16, r.Offset())
+}
+
+func TestReaderErrorChain(t *testing.T) {
+
+	// note: errors wrap ErrOutOfRange through the whole reader stack
+	_, err := NewReader([]byte{0xFF}).ReadVendorSection()
+	require.ErrorIs(t, err, ErrOutOfRange)
+	require.EqualError(t, err, "ReadInt failed: ReadBits failed: read bits (index=0, length=16): bits: length extends beyond range")
+}
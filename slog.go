@@ -0,0 +1,164 @@
+package iabtcf
+
+import (
+	"iter"
+	"log/slog"
+	"slices"
+)
+
+// //////////////////////////////////////////////////
+// slog integration
+//
+// Consents are logged as a compact group, so logs stay structured and bounded whatever the number of vendors:
+//
+//	consent.version=2 consent.cmp_id=92 consent.cmp_version=1 consent.vendor_list_version=34
+//	consent.purposes=[1 2 3] consent.vendor_count=2 consent.li_vendor_count=3 consent.segments=[core disclosed_vendors]
+//
+// The full vendor lists are only logged when wrapped with LogWithVendors.
+
+var (
+	_ slog.LogValuer = (*Consent)(nil)
+	_ slog.LogValuer = (*LazyConsent)(nil)
+)
+
+// segmentNames are the names of the segment types, as logged
+var segmentNames = map[int]string{
+	disclosedVendorsSegmentType: "disclosed_vendors",
+	allowedVendorsSegmentType:   "allowed_vendors",
+	publisherTCSegmentType:      "publisher_tc",
+}
+
+// LogValue implements slog.LogValuer
+func (p *Consent) LogValue() slog.Value {
+	return p.logFields().value(false)
+}
+
+// LogValue implements slog.LogValuer
+func (c *LazyConsent) LogValue() slog.Value {
+	return c.logFields().value(false)
+}
+
+// LogWithVendors returns a slog.LogValuer logging the consent along with its full vendor lists
+//
+// note: c is expected to be a *Consent, a *LazyConsent or an *ImmutableConsent, other implementations are logged as is
+func LogWithVendors(c ConsentView) slog.LogValuer {
+	return verboseConsent{c}
+}
+
+type verboseConsent struct {
+	c ConsentView
+}
+
+// LogValue implements slog.LogValuer
+func (v verboseConsent) LogValue() slog.Value {
+	switch c := v.c.(type) {
+	case *Consent:
+		return c.logFields().value(true)
+	case *LazyConsent:
+		return c.logFields().value(true)
+	case *ImmutableConsent:
+		return LogWithVendors(c.view()).LogValue()
+	default:
+		return slog.AnyValue(c)
+	}
+}
+
+// logFields are the fields logged for a consent, shared by Consent and LazyConsent
+type logFields struct {
+	version           int
+	cmpID             int
+	cmpVersion        int
+	vendorListVersion int
+	purposes          iter.Seq[int]
+	vendors           iter.Seq[int]
+	liVendors         iter.Seq[int]
+	segments          []string
+}
+
+func (p *Consent) logFields() *logFields {
+	if p == nil {
+		return nil
+	}
+	segments := []string{"core"}
+	if p.DisclosedVendorsSegment != nil {
+		segments = append(segments, segmentNames[disclosedVendorsSegmentType])
+	}
+	if p.AllowedVendorsSegment != nil {
+		segments = append(segments, segmentNames[allowedVendorsSegmentType])
+	}
+	if p.PublisherTC != nil {
+		segments = append(segments, segmentNames[publisherTCSegmentType])
+	}
+	return &logFields{
+		version:           p.Version,
+		cmpID:             p.CMPID,
+		cmpVersion:        p.CMPVersion,
+		vendorListVersion: p.VendorListVersion,
+		purposes:          p.AllowedPurposes(),
+		vendors:           p.ConsentedVendorIDs(),
+		liVendors:         p.LIVendors(),
+		segments:          segments,
+	}
+}
+
+func (c *LazyConsent) logFields() *logFields {
+	if c == nil {
+		return nil
+	}
+	segments := []string{"core"}
+	for _, block := range c.Extras {
+		if name, ok := segmentNames[block.ReadIntField(0, 3)]; ok && !slices.Contains(segments, name) {
+			segments = append(segments, name)
+		}
+	}
+	return &logFields{
+		version:           c.Version(),
+		cmpID:             c.CMPID(),
+		cmpVersion:        c.CMPVersion(),
+		vendorListVersion: c.VendorListVersion(),
+		purposes:          c.AllowedPurposes(),
+		vendors:           c.ConsentedVendorIDs(),
+		liVendors:         c.LIVendors(),
+		segments:          segments,
+	}
+}
+
+// value returns the group of the fields, with the vendor lists if withVendors is true, else with their count
+func (f *logFields) value(withVendors bool) slog.Value {
+	if f == nil {
+		return slog.GroupValue()
+	}
+	attrs := []slog.Attr{
+		slog.Int("version", f.version),
+		slog.Int("cmp_id", f.cmpID),
+		slog.Int("cmp_version", f.cmpVersion),
+		slog.Int("vendor_list_version", f.vendorListVersion),
+		slog.Any("purposes", collectIDs(f.purposes)),
+	}
+	if withVendors {
+		attrs = append(attrs,
+			slog.Any("vendors", collectIDs(f.vendors)),
+			slog.Any("li_vendors", collectIDs(f.liVendors)),
+		)
+	} else {
+		attrs = append(attrs,
+			slog.Int("vendor_count", countIDs(f.vendors)),
+			slog.Int("li_vendor_count", countIDs(f.liVendors)),
+		)
+	}
+	attrs = append(attrs, slog.Any("segments", f.segments))
+	return slog.GroupValue(attrs...)
+}
+
+// collectIDs returns the IDs of the iterator, an empty slice rather than nil so it's logged as []
+func collectIDs(ids iter.Seq[int]) []int {
+	return slices.AppendSeq([]int{}, ids)
+}
+
+func countIDs(ids iter.Seq[int]) int {
+	n := 0
+	for range ids {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,71 @@
+package iabtcf
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogValue(t *testing.T) {
+
+	tcString := encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(testPublisherTCBits)
+	consent, err := ParseCoreString(tcString)
+	require.NoError(t, err, "unexpected parse error")
+	lazy, err := LazyParseCoreString(tcString)
+	require.NoError(t, err, "unexpected lazy parse error")
+
+	compact := `consent.version=2 consent.cmp_id=92 consent.cmp_version=1 consent.vendor_list_version=34 consent.purposes="[1 2 3]" ` +
+		`consent.vendor_count=2 consent.li_vendor_count=3 consent.segments="[core disclosed_vendors publisher_tc]"`
+	verbose := `consent.version=2 consent.cmp_id=92 consent.cmp_version=1 consent.vendor_list_version=34 consent.purposes="[1 2 3]" ` +
+		`consent.vendors="[1 5]" consent.li_vendors="[10 11 12]" consent.segments="[core disclosed_vendors publisher_tc]"`
+
+	tests := map[string]struct {
+		value any
+		want  string
+	}{
+		"consent": {
+			value: consent,
+			want:  compact,
+		},
+		"lazy-consent": {
+			value: lazy,
+			want:  compact,
+		},
+		"consent-with-vendors": {
+			value: LogWithVendors(consent),
+			want:  verbose,
+		},
+		"lazy-consent-with-vendors": {
+			value: LogWithVendors(lazy),
+			want:  verbose,
+		},
+		"core-only": {
+			value: &Consent{Version: 2, CMPID: 1},
+			want: `consent.version=2 consent.cmp_id=1 consent.cmp_version=0 consent.vendor_list_version=0 consent.purposes=[] ` +
+				`consent.vendor_count=0 consent.li_vendor_count=0 consent.segments=[core]`,
+		},
+		"nil-consent": {
+			value: (*Consent)(nil),
+			want:  ``,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+			logger.Info("", "consent", tc.value)
+			require.Equal(t, tc.want, strings.TrimSpace(buf.String()))
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package iabtcf
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// TCData represents the object returned by the CMP JavaScript API: __tcfapi('getTCData', 2, callback, vendorIds)
+//
+// It's implemented according to specification: https://github.com/InteractiveAdvertisingBureau/GDPR-Transparency-and-Consent-Framework/blob/master/TCFv2/IAB%20Tech%20Lab%20-%20CMP%20API%20v2.md#tcdata
+//
+// note: ID maps only list the IDs which are set, an absent ID means false.
+// When a vendor ID list is given, vendor maps list every requested vendor ID with its value instead.
+type TCData struct {
+	TCString             string          `json:"tcString"`
+	TcfPolicyVersion     int             `json:"tcfPolicyVersion"`
+	CMPID                int             `json:"cmpId"`
+	CMPVersion           int             `json:"cmpVersion"`
+	GDPRApplies          *bool           `json:"gdprApplies,omitempty"`
+	EventStatus          string          `json:"eventStatus,omitempty"`
+	CMPStatus            string          `json:"cmpStatus,omitempty"`
+	ListenerID           *int            `json:"listenerId,omitempty"`
+	IsServiceSpecific    bool            `json:"isServiceSpecific"`
+	UseNonStandardTexts  bool            `json:"useNonStandardTexts"`
+	PublisherCC          string          `json:"publisherCC"`
+	PurposeOneTreatment  bool            `json:"purposeOneTreatment"`
+	OutOfBand            TCDataOutOfBand `json:"outOfBand"`
+	Purpose              TCDataConsents  `json:"purpose"`
+	Vendor               TCDataConsents  `json:"vendor"`
+	SpecialFeatureOptIns map[int]bool    `json:"specialFeatureOptins"`
+	Publisher            TCDataPublisher `json:"publisher"`
+}
+
+// TCDataOutOfBand represents the out of band vendors of a TCData object
+type TCDataOutOfBand struct {
+	AllowedVendors   map[int]bool `json:"allowedVendors"`
+	DisclosedVendors map[int]bool `json:"disclosedVendors"`
+}
+
+// TCDataConsents represents the consents and legitimate interests of a TCData object
+type TCDataConsents struct {
+	Consents            map[int]bool `json:"consents"`
+	LegitimateInterests map[int]bool `json:"legitimateInterests"`
+}
+
+// TCDataPublisher represents the publisher part of a TCData object
+//
+// note: restrictions are indexed by purpose ID then by vendor ID
+type TCDataPublisher struct {
+	Consents            map[int]bool                    `json:"consents"`
+	LegitimateInterests map[int]bool                    `json:"legitimateInterests"`
+	CustomPurpose       TCDataConsents                  `json:"customPurpose"`
+	Restrictions        map[int]map[int]RestrictionType `json:"restrictions"`
+}
+
+// TCData builds the TCData object of the CMP JavaScript API from the Consent
+//
+// tcString is the string the Consent was parsed from, gdprApplies is omitted when nil.
+// If vendorIDs is not empty, the vendor maps only contain the given vendor IDs.
+func (p *Consent) TCData(tcString string, gdprApplies *bool, vendorIDs []int) *TCData {
+	d := &TCData{
+		TCString:             tcString,
+		TcfPolicyVersion:     p.TcfPolicyVersion,
+		CMPID:                p.CMPID,
+		CMPVersion:           p.CMPVersion,
+		GDPRApplies:          gdprApplies,
+		IsServiceSpecific:    p.IsServiceSpecific,
+		UseNonStandardTexts:  p.UseNonStandardStacks,
+		PublisherCC:          p.PublisherCC,
+		PurposeOneTreatment:  p.PurposeOneTreatment,
+		SpecialFeatureOptIns: idsToMap(p.SpecialFeatureOptIns.ToIDs(), nil),
+		Purpose: TCDataConsents{
+			Consents:            idsToMap(p.PurposesConsent.ToIDs(), nil),
+			LegitimateInterests: idsToMap(p.PurposesLITransparency.ToIDs(), nil),
+		},
+		OutOfBand: TCDataOutOfBand{
+			AllowedVendors:   map[int]bool{},
+			DisclosedVendors: map[int]bool{},
+		},
+		Publisher: TCDataPublisher{
+			Consents:            map[int]bool{},
+			LegitimateInterests: map[int]bool{},
+			CustomPurpose: TCDataConsents{
+				Consents:            map[int]bool{},
+				LegitimateInterests: map[int]bool{},
+			},
+			Restrictions: map[int]map[int]RestrictionType{},
+		},
+	}
+
+	if p.IsRangeEncoding {
+		d.Vendor.Consents = idsToMap(rangeEntriesToIDs(p.RangeEntries), vendorIDs)
+	} else {
+		d.Vendor.Consents = idsToMap(p.ConsentedVendors.ToIDs(), vendorIDs)
+	}
+	d.Vendor.LegitimateInterests = idsToMap(p.VendorLegitimateInterests.ids(), vendorIDs)
+
+	if p.AllowedVendorsSegment != nil {
+		d.OutOfBand.AllowedVendors = idsToMap(p.AllowedVendorsSegment.ids(), vendorIDs)
+	}
+	if p.DisclosedVendorsSegment != nil {
+		d.OutOfBand.DisclosedVendors = idsToMap(p.DisclosedVendorsSegment.ids(), vendorIDs)
+	}
+
+	if p.PublisherTC != nil {
+		d.Publisher.Consents = idsToMap(p.PublisherTC.PubPurposesConsent.ToIDs(), nil)
+		d.Publisher.LegitimateInterests = idsToMap(p.PublisherTC.PubPurposesLITransparency.ToIDs(), nil)
+		d.Publisher.CustomPurpose.Consents = idsToMap(p.PublisherTC.CustomPurposesConsent.ToIDs(), nil)
+		d.Publisher.CustomPurpose.LegitimateInterests = idsToMap(p.PublisherTC.CustomPurposesLITransparency.ToIDs(), nil)
+	}
+
+	// note: the vendor IDs are looked up in a bit set, built once for all the restrictions
+	filter := FromIDs(vendorIDs)
+	for _, pr := range p.PublisherRestrictions {
+		for _, id := range rangeEntriesToIDs(pr.RangeEntries) {
+			if len(vendorIDs) > 0 && !filter.HasBit(id) {
+				continue
+			}
+			restrictions, ok := d.Publisher.Restrictions[pr.PurposeID]
+			if !ok {
+				restrictions = map[int]RestrictionType{}
+				d.Publisher.Restrictions[pr.PurposeID] = restrictions
+			}
+			restrictions[id] = pr.RestrictionType
+		}
+	}
+
+	return d
+}
+
+// Consent builds a Consent object from the TCData object, so it can be encoded with EncodeCoreString
+//
+// note: the tcString field is ignored, the Consent is only built from the decoded fields.
+// Since TCData doesn't contain them, created, last updated, consent screen, consent language and
+// vendor list version are left empty: the caller is expected to set them before encoding.
+// Vendor sections are built as bitfields, and publisher restrictions as the smallest list of ranges.
+// Out of band and publisher segments are only built when at least one of their IDs is set.
+//
+// note: an error is returned when an ID is lower than 1 or greater than 65535, since it can't be encoded.
+func (d *TCData) Consent() (*Consent, error) {
+	p := &Consent{
+		Version:              2,
+		CMPID:                d.CMPID,
+		CMPVersion:           d.CMPVersion,
+		TcfPolicyVersion:     d.TcfPolicyVersion,
+		IsServiceSpecific:    d.IsServiceSpecific,
+		UseNonStandardStacks: d.UseNonStandardTexts,
+		PurposeOneTreatment:  d.PurposeOneTreatment,
+		PublisherCC:          d.PublisherCC,
+	}
+
+	var err error
+	if p.SpecialFeatureOptIns, err = idsToBits(mapToIDs(d.SpecialFeatureOptIns), SpecialFeatureOptInsField.NbBits); err != nil {
+		return nil, fmt.Errorf("specialFeatureOptins: %w", err)
+	}
+	if p.PurposesConsent, err = idsToBits(mapToIDs(d.Purpose.Consents), PurposesConsentField.NbBits); err != nil {
+		return nil, fmt.Errorf("purpose.consents: %w", err)
+	}
+	if p.PurposesLITransparency, err = idsToBits(mapToIDs(d.Purpose.LegitimateInterests), PurposesLITransparencyField.NbBits); err != nil {
+		return nil, fmt.Errorf("purpose.legitimateInterests: %w", err)
+	}
+
+	consented, err := idsToVendorSection(mapToIDs(d.Vendor.Consents))
+	if err != nil {
+		return nil, fmt.Errorf("vendor.consents: %w", err)
+	}
+	p.MaxVendorID = consented.MaxVendorID
+	p.ConsentedVendors = consented.BitField
+
+	if p.VendorLegitimateInterests, err = idsToVendorSection(mapToIDs(d.Vendor.LegitimateInterests)); err != nil {
+		return nil, fmt.Errorf("vendor.legitimateInterests: %w", err)
+	}
+
+	if ids := mapToIDs(d.OutOfBand.DisclosedVendors); len(ids) > 0 {
+		s, err := idsToVendorSection(ids)
+		if err != nil {
+			return nil, fmt.Errorf("outOfBand.disclosedVendors: %w", err)
+		}
+		p.DisclosedVendorsSegment = &s
+	}
+	if ids := mapToIDs(d.OutOfBand.AllowedVendors); len(ids) > 0 {
+		s, err := idsToVendorSection(ids)
+		if err != nil {
+			return nil, fmt.Errorf("outOfBand.allowedVendors: %w", err)
+		}
+		p.AllowedVendorsSegment = &s
+	}
+
+	pubConsents := mapToIDs(d.Publisher.Consents)
+	pubLI := mapToIDs(d.Publisher.LegitimateInterests)
+	customConsents := mapToIDs(d.Publisher.CustomPurpose.Consents)
+	customLI := mapToIDs(d.Publisher.CustomPurpose.LegitimateInterests)
+	if len(pubConsents)+len(pubLI)+len(customConsents)+len(customLI) > 0 {
+		tc := &PublisherTC{
+			NumCustomPurposes: slices.Max(append([]int{0}, append(customConsents, customLI...)...)),
+		}
+		if tc.PubPurposesConsent, err = idsToBits(pubConsents, 24); err != nil {
+			return nil, fmt.Errorf("publisher.consents: %w", err)
+		}
+		if tc.PubPurposesLITransparency, err = idsToBits(pubLI, 24); err != nil {
+			return nil, fmt.Errorf("publisher.legitimateInterests: %w", err)
+		}
+		if tc.CustomPurposesConsent, err = idsToBits(customConsents, tc.NumCustomPurposes); err != nil {
+			return nil, fmt.Errorf("publisher.customPurpose.consents: %w", err)
+		}
+		if tc.CustomPurposesLITransparency, err = idsToBits(customLI, tc.NumCustomPurposes); err != nil {
+			return nil, fmt.Errorf("publisher.customPurpose.legitimateInterests: %w", err)
+		}
+		p.PublisherTC = tc
+	}
+
+	p.PublisherRestrictions = make([]PublisherRestriction, 0)
+	for _, purposeID := range slices.Sorted(maps.Keys(d.Publisher.Restrictions)) {
+		// group vendors by restriction type
+		byType := map[RestrictionType][]int{}
+		for vendorID, restrictionType := range d.Publisher.Restrictions[purposeID] {
+			byType[restrictionType] = append(byType[restrictionType], vendorID)
+		}
+		for _, restrictionType := range slices.Sorted(maps.Keys(byType)) {
+			entries, err := idsToRangeEntries(byType[restrictionType])
+			if err != nil {
+				return nil, fmt.Errorf("publisher.restrictions: %w", err)
+			}
+			p.PublisherRestrictions = append(p.PublisherRestrictions, PublisherRestriction{
+				PurposeID:       purposeID,
+				RestrictionType: restrictionType,
+				NumEntries:      len(entries),
+				RangeEntries:    entries,
+			})
+		}
+	}
+
+	return p, nil
+}
+
+// mapToIDs returns the IDs set to true, in ascending order
+func mapToIDs(m map[int]bool) []int {
+	ids := make([]int, 0, len(m))
+	for id, value := range m {
+		if value {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// idsToMap returns a map with every ID set to true
+//
+// note: if filter is not empty, the map contains exactly the filter IDs, set to true if they are in ids
+func idsToMap(ids []int, filter []int) map[int]bool {
+	if len(filter) == 0 {
+		m := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			m[id] = true
+		}
+		return m
+	}
+	m := make(map[int]bool, len(filter))
+	for _, id := range filter {
+		_, m[id] = slices.BinarySearch(ids, id)
+	}
+	return m
+}
@@ -0,0 +1,124 @@
+package iabtcf
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTCData(t *testing.T) {
+
+	gdprApplies := true
+	tcString := encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(testPublisherTCBits)
+
+	tests := map[string]struct {
+		gdprApplies *bool
+		vendorIDs   []int
+		want        string
+	}{
+		"all-vendors": {
+			gdprApplies: &gdprApplies,
+			want: `{"tcString":"` + tcString + `","tcfPolicyVersion":2,"cmpId":92,"cmpVersion":1,"gdprApplies":true,` +
+				`"isServiceSpecific":false,"useNonStandardTexts":false,"publisherCC":"AA","purposeOneTreatment":false,` +
+				`"outOfBand":{"allowedVendors":{},"disclosedVendors":{"1":true,"2":true,"5":true}},` +
+				`"purpose":{"consents":{"1":true,"2":true,"3":true},"legitimateInterests":{"4":true}},` +
+				`"vendor":{"consents":{"1":true,"5":true},"legitimateInterests":{"10":true,"11":true,"12":true}},` +
+				`"specialFeatureOptins":{"1":true},` +
+				`"publisher":{"consents":{"1":true},"legitimateInterests":{"2":true},` +
+				`"customPurpose":{"consents":{"2":true},"legitimateInterests":{"1":true}},` +
+				`"restrictions":{"2":{"5":1}}}}`,
+		},
+		"filtered-vendors": {
+			vendorIDs: []int{1, 2, 11},
+			want: `{"tcString":"` + tcString + `","tcfPolicyVersion":2,"cmpId":92,"cmpVersion":1,` +
+				`"isServiceSpecific":false,"useNonStandardTexts":false,"publisherCC":"AA","purposeOneTreatment":false,` +
+				`"outOfBand":{"allowedVendors":{},"disclosedVendors":{"1":true,"2":true,"11":false}},` +
+				`"purpose":{"consents":{"1":true,"2":true,"3":true},"legitimateInterests":{"4":true}},` +
+				`"vendor":{"consents":{"1":true,"2":false,"11":false},"legitimateInterests":{"1":false,"2":false,"11":true}},` +
+				`"specialFeatureOptins":{"1":true},` +
+				`"publisher":{"consents":{"1":true},"legitimateInterests":{"2":true},` +
+				`"customPurpose":{"consents":{"2":true},"legitimateInterests":{"1":true}},` +
+				`"restrictions":{}}}`,
+		},
+		"filtered-restricted-vendor": {
+			vendorIDs: []int{5, 7},
+			want: `{"tcString":"` + tcString + `","tcfPolicyVersion":2,"cmpId":92,"cmpVersion":1,` +
+				`"isServiceSpecific":false,"useNonStandardTexts":false,"publisherCC":"AA","purposeOneTreatment":false,` +
+				`"outOfBand":{"allowedVendors":{},"disclosedVendors":{"5":true,"7":false}},` +
+				`"purpose":{"consents":{"1":true,"2":true,"3":true},"legitimateInterests":{"4":true}},` +
+				`"vendor":{"consents":{"5":true,"7":false},"legitimateInterests":{"5":false,"7":false}},` +
+				`"specialFeatureOptins":{"1":true},` +
+				`"publisher":{"consents":{"1":true},"legitimateInterests":{"2":true},` +
+				`"customPurpose":{"consents":{"2":true},"legitimateInterests":{"1":true}},` +
+				`"restrictions":{"2":{"5":1}}}}`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := ParseCoreString(tcString)
+			require.NoError(t, err, "unexpected parse error")
+
+			got, err := json.Marshal(parsed.TCData(tcString, tc.gdprApplies, tc.vendorIDs))
+			require.NoError(t, err, "unexpected marshal error")
+			require.JSONEq(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestTCDataConsent(t *testing.T) {
+
+	tcString := encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(testPublisherTCBits)
+	parsed, err := ParseCoreString(tcString)
+	require.NoError(t, err, "unexpected parse error")
+	want := parsed.TCData(tcString, nil, nil)
+
+	// replay the TCData object as captured from a browser
+	data, err := json.Marshal(want)
+	require.NoError(t, err, "unexpected marshal error")
+	var captured TCData
+	require.NoError(t, json.Unmarshal(data, &captured), "unexpected unmarshal error")
+
+	consent, err := captured.Consent()
+	require.NoError(t, err, "unexpected error")
+	encoded, err := EncodeCoreString(consent)
+	require.NoError(t, err, "unexpected encode error")
+	replayed, err := ParseCoreString(encoded)
+	require.NoError(t, err, "unexpected parse error of the encoded string")
+
+	got := replayed.TCData(tcString, nil, nil)
+	require.Equal(t, want, got, "wrong TCData after replay")
+}
+
+func TestTCDataConsentInvalidID(t *testing.T) {
+
+	type TestCase struct {
+		data    string
+		wantErr string
+	}
+
+	testCases := map[string]*TestCase{
+		"vendor-consent-above-max": {
+			data:    `{"vendor":{"consents":{"1":true,"65536":true}}}`,
+			wantErr: "vendor.consents: invalid id 65536",
+		},
+		"disclosed-vendor-zero": {
+			data:    `{"outOfBand":{"disclosedVendors":{"0":true}}}`,
+			wantErr: "outOfBand.disclosedVendors: invalid id 0",
+		},
+		"restricted-vendor-above-max": {
+			data:    `{"publisher":{"restrictions":{"2":{"2147483647":1}}}}`,
+			wantErr: "publisher.restrictions: invalid id 2147483647",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var d TCData
+			require.NoError(t, json.Unmarshal([]byte(tc.data), &d), "unexpected unmarshal error")
+			_, err := d.Consent()
+			require.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
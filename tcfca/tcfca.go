@@ -0,0 +1,289 @@
+// Package tcfca implements a lazy parser of the TCF Canada consent string ( GPP section 5 )
+//
+// TCF Canada shares most of its fields with the TCF EU core string, but distinguishes express and implied consent,
+// and encodes vendor lists as optimized ranges using Fibonacci encoding.
+// As for iabtcf.LazyConsent, the parser only decodes the base64 segments, and fields are read when accessed.
+//
+// It's implemented according to specification: https://github.com/InteractiveAdvertisingBureau/Global-Privacy-Platform/blob/main/Sections/TCF%20Canada/TCF%20Canada%20Section.md
+package tcfca
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/travelaudience/go-iabtcf"
+)
+
+// segment types of the optional segments following the core segment
+const (
+	disclosedVendorsSegmentType = 1
+	pubPurposesSegmentType      = 3
+)
+
+// Parse parses a TCF Canada string into a Consent
+//
+// note: as for iabtcf.LazyParseCoreString, the minimum length is checked so every fixed field can be accessed,
+// and for the vendor part, if the string is too short or invalid, the vendor will be considered as not allowed.
+func Parse(s string) (*Consent, error) {
+	if s == "" {
+		return nil, fmt.Errorf("consent string is empty")
+	}
+	everything := strings.Split(s, ".")
+
+	bytes, err := base64.RawURLEncoding.DecodeString(everything[0])
+	if err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
+	}
+
+	consent := &Consent{Core: iabtcf.Bits(bytes)}
+	for _, extra := range everything[1:] {
+		if bytes, err := base64.RawURLEncoding.DecodeString(extra); err == nil {
+			consent.Extras = append(consent.Extras, iabtcf.Bits(bytes))
+		}
+	}
+
+	if consent.Core.Length() < VendorExpressConsentOffset {
+		return nil, fmt.Errorf("consent string is too short")
+	}
+
+	return consent, nil
+}
+
+// //////////////////////////////////////////////////
+// consent
+
+// Consent provides methods to extract data of a TCF Canada string in a lazy mode
+type Consent struct {
+	Core   iabtcf.Bits
+	Extras []iabtcf.Bits
+}
+
+// Version returns the version of the consent string
+func (c *Consent) Version() int {
+	return c.Core.ReadIntField(VersionField.Offset, VersionField.NbBits)
+}
+
+// Created returns the creation date of the consent string
+func (c *Consent) Created() time.Time {
+	return c.Core.ReadTimeField(CreatedField.Offset)
+}
+
+// LastUpdated returns the last update date of the consent string
+func (c *Consent) LastUpdated() time.Time {
+	return c.Core.ReadTimeField(LastUpdatedField.Offset)
+}
+
+// CMPID returns the Consent Management Platform ID
+func (c *Consent) CMPID() int {
+	return c.Core.ReadIntField(CMPIDField.Offset, CMPIDField.NbBits)
+}
+
+// CMPVersion returns the Consent Management Platform version
+func (c *Consent) CMPVersion() int {
+	return c.Core.ReadIntField(CMPVersionField.Offset, CMPVersionField.NbBits)
+}
+
+// ConsentScreen returns the consent screen number
+func (c *Consent) ConsentScreen() int {
+	return c.Core.ReadIntField(ConsentScreenField.Offset, ConsentScreenField.NbBits)
+}
+
+// ConsentLanguage returns the consent language
+func (c *Consent) ConsentLanguage() string {
+	return c.Core.ReadStringField(ConsentLanguageField.Offset, ConsentLanguageField.NbBits)
+}
+
+// VendorListVersion returns the vendor list version
+func (c *Consent) VendorListVersion() int {
+	return c.Core.ReadIntField(VendorListVersionField.Offset, VendorListVersionField.NbBits)
+}
+
+// TcfPolicyVersion returns the TCF policy version
+func (c *Consent) TcfPolicyVersion() int {
+	return c.Core.ReadIntField(TcfPolicyVersionField.Offset, TcfPolicyVersionField.NbBits)
+}
+
+// UseNonStandardStacks checks if the consent uses non standard stacks
+func (c *Consent) UseNonStandardStacks() bool {
+	return c.Core.ReadBoolField(UseNonStandardStacksField.Offset)
+}
+
+// SpecialFeatureExpressConsent checks if special feature has express consent
+func (c *Consent) SpecialFeatureExpressConsent(number int) bool {
+	return c.Core.ReadBitNumber(number, SpecialFeatureExpressConsentField.Offset, SpecialFeatureExpressConsentField.NbBits)
+}
+
+// PurposeExpressConsent checks if purpose has express consent
+func (c *Consent) PurposeExpressConsent(number int) bool {
+	return c.Core.ReadBitNumber(number, PurposesExpressConsentField.Offset, PurposesExpressConsentField.NbBits)
+}
+
+// PurposeImpliedConsent checks if purpose has implied consent
+func (c *Consent) PurposeImpliedConsent(number int) bool {
+	return c.Core.ReadBitNumber(number, PurposesImpliedConsentField.Offset, PurposesImpliedConsentField.NbBits)
+}
+
+// VendorExpressConsent checks if vendor has express consent
+func (c *Consent) VendorExpressConsent(number int) bool {
+	allowed, _ := hasVendor(c.Core, VendorExpressConsentOffset, number)
+	return allowed
+}
+
+// VendorImpliedConsent checks if vendor has implied consent
+//
+// note: the vendor express consent section is walked to find the offset of the vendor implied consent section.
+func (c *Consent) VendorImpliedConsent(number int) bool {
+	_, next := hasVendor(c.Core, VendorExpressConsentOffset, 0)
+	if next == 0 {
+		return false
+	}
+	allowed, _ := hasVendor(c.Core, next, number)
+	return allowed
+}
+
+// HasDisclosedVendorsBlock returns true if there is at least one disclosed vendors block
+func (c *Consent) HasDisclosedVendorsBlock() bool {
+	return c.block(disclosedVendorsSegmentType) != nil
+}
+
+// IsVendorDisclosed returns true if the given vendor ID is found in a disclosed vendors block
+func (c *Consent) IsVendorDisclosed(number int) bool {
+	for _, block := range c.Extras {
+		if block.ReadIntField(0, 3) != disclosedVendorsSegmentType {
+			continue
+		}
+		if allowed, _ := hasVendor(block, 3, number); allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPubPurposesBlock returns true if there is a publisher purposes block
+func (c *Consent) HasPubPurposesBlock() bool {
+	return c.block(pubPurposesSegmentType) != nil
+}
+
+// PubPurposeExpressConsent checks if publisher purpose has express consent
+//
+// note: returns false if there is no publisher purposes block
+func (c *Consent) PubPurposeExpressConsent(number int) bool {
+	block := c.block(pubPurposesSegmentType)
+	return block.ReadBitNumber(number, PubPurposesExpressConsentField.Offset, PubPurposesExpressConsentField.NbBits)
+}
+
+// PubPurposeImpliedConsent checks if publisher purpose has implied consent
+//
+// note: returns false if there is no publisher purposes block
+func (c *Consent) PubPurposeImpliedConsent(number int) bool {
+	block := c.block(pubPurposesSegmentType)
+	return block.ReadBitNumber(number, PubPurposesImpliedConsentField.Offset, PubPurposesImpliedConsentField.NbBits)
+}
+
+// NumCustomPurposes returns the number of custom purposes of the publisher purposes block
+func (c *Consent) NumCustomPurposes() int {
+	block := c.block(pubPurposesSegmentType)
+	if block == nil {
+		return 0
+	}
+	return block.ReadIntField(NumCustomPurposesField.Offset, NumCustomPurposesField.NbBits)
+}
+
+// CustomPurposeExpressConsent checks if custom purpose has express consent
+func (c *Consent) CustomPurposeExpressConsent(number int) bool {
+	return c.block(pubPurposesSegmentType).ReadBitNumber(number, CustomPurposesOffset, c.NumCustomPurposes())
+}
+
+// CustomPurposeImpliedConsent checks if custom purpose has implied consent
+func (c *Consent) CustomPurposeImpliedConsent(number int) bool {
+	n := c.NumCustomPurposes()
+	return c.block(pubPurposesSegmentType).ReadBitNumber(number, CustomPurposesOffset+n, n)
+}
+
+// block returns the first extra block of the given segment type, nil if absent
+func (c *Consent) block(segmentType int) *iabtcf.Bits {
+	for i := range c.Extras {
+		if c.Extras[i].ReadIntField(0, 3) == segmentType {
+			return &c.Extras[i]
+		}
+	}
+	return nil
+}
+
+// hasVendor checks if vendor is in the optimized range starting at offset,
+// and returns the offset following the optimized range ( 0 if it's invalid )
+//
+// An optimized range is made of a 16 bits max vendor id and an encoding type bit, followed by
+// either a bitfield of max vendor id bits or a Fibonacci encoded range.
+func hasVendor(b iabtcf.Bits, offset, number int) (bool, int) {
+	maxVendorID := b.ReadIntField(offset, 16)
+	isRangeEncoding := b.ReadBoolField(offset + 16)
+	offset += 17
+
+	if !isRangeEncoding {
+		return b.ReadBitNumber(number, offset, maxVendorID), offset + maxVendorID
+	}
+
+	numEntries := b.ReadIntField(offset, 12)
+	offset += 12
+	found := false
+	last := 0
+	for range numEntries {
+		isRange := b.ReadBoolField(offset)
+		offset++
+		start, nbBits := b.ReadFibonacciIntField(offset)
+		if nbBits == 0 {
+			return false, 0
+		}
+		offset += nbBits
+		start += last
+		end := start
+		if isRange {
+			length, nbBits := b.ReadFibonacciIntField(offset)
+			if nbBits == 0 {
+				return false, 0
+			}
+			offset += nbBits
+			end = start + length
+		}
+		last = end
+		if start <= number && number <= end {
+			found = true
+		}
+	}
+	return found, offset
+}
+
+// //////////////////////////////////////////////////
+// consent field helpers
+//
+// note: the offsets are explicit, since the iabtcf field constructors chain the fields of the TCF EU core string.
+
+var (
+	VersionField                      = &iabtcf.ConsentField{Offset: 0, NbBits: 6}
+	CreatedField                      = &iabtcf.ConsentField{Offset: 6, NbBits: 36}
+	LastUpdatedField                  = &iabtcf.ConsentField{Offset: 42, NbBits: 36}
+	CMPIDField                        = &iabtcf.ConsentField{Offset: 78, NbBits: 12}
+	CMPVersionField                   = &iabtcf.ConsentField{Offset: 90, NbBits: 12}
+	ConsentScreenField                = &iabtcf.ConsentField{Offset: 102, NbBits: 6}
+	ConsentLanguageField              = &iabtcf.ConsentField{Offset: 108, NbBits: 12}
+	VendorListVersionField            = &iabtcf.ConsentField{Offset: 120, NbBits: 12}
+	TcfPolicyVersionField             = &iabtcf.ConsentField{Offset: 132, NbBits: 6}
+	UseNonStandardStacksField         = &iabtcf.ConsentField{Offset: 138, NbBits: 1}
+	SpecialFeatureExpressConsentField = &iabtcf.ConsentField{Offset: 139, NbBits: 12}
+	PurposesExpressConsentField       = &iabtcf.ConsentField{Offset: 151, NbBits: 24}
+	PurposesImpliedConsentField       = &iabtcf.ConsentField{Offset: 175, NbBits: 24}
+
+	// vendor express consent then vendor implied consent, each as an optimized range
+	VendorExpressConsentOffset = PurposesImpliedConsentField.NextOffset()
+
+	// publisher purposes block, after the 3 bits segment type
+	PubPurposesExpressConsentField = &iabtcf.ConsentField{Offset: 3, NbBits: 24}
+	PubPurposesImpliedConsentField = &iabtcf.ConsentField{Offset: 27, NbBits: 24}
+	NumCustomPurposesField         = &iabtcf.ConsentField{Offset: 51, NbBits: 6}
+
+	// custom purposes express consent then custom purposes implied consent, each of num custom purposes bits
+	CustomPurposesOffset = NumCustomPurposesField.NextOffset()
+)
@@ -0,0 +1,200 @@
+package tcfca
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/travelaudience/go-iabtcf"
+)
+
+var (
+	// core segment: version 1, created / last updated 2017-07-14T02:40:00Z, cmp 92, cmp version 1, screen 3, language EN,
+	// vendor list version 34, policy 2, special feature 1, purposes express [1 3], purposes implied [2],
+	// vendors express {1,5} as bitfield, vendors implied {3,10,11,12} as Fibonacci range
+	testCoreBits = sprintb(1, 6) + sprintb(15e9, 36) + sprintb(15e9, 36) + sprintb(92, 12) + sprintb(1, 12) + sprintb(3, 6) +
+		sprintb(4, 6) + sprintb(13, 6) + sprintb(34, 12) + sprintb(2, 6) + "0" +
+		"100000000000" + "101000000000000000000000" + "010000000000000000000000" +
+		sprintb(5, 16) + "0" + "10001" +
+		sprintb(12, 16) + "1" + sprintb(2, 12) + "0" + fibonacci(3) + "1" + fibonacci(7) + fibonacci(2)
+
+	// disclosed vendors segment: vendors 1 to 3
+	testDisclosedVendorsBits = "001" + sprintb(3, 16) + "1" + sprintb(1, 12) + "1" + fibonacci(1) + fibonacci(2)
+
+	// publisher purposes segment: express [1], implied [2], 2 custom purposes, custom express [2], custom implied [1]
+	testPubPurposesBits = "011" + "100000000000000000000000" + "010000000000000000000000" + sprintb(2, 6) + "01" + "10"
+)
+
+func TestParse(t *testing.T) {
+
+	type TestCase struct {
+		consent string
+		wantErr string
+	}
+
+	testCases := map[string]*TestCase{
+		"empty": {
+			consent: "",
+			wantErr: "consent string is empty",
+		},
+		"invalid-base64": {
+			consent: "A",
+			wantErr: "decode failed: illegal base64 data at input byte 0",
+		},
+		"too-short": {
+			consent: encodeBits(testCoreBits[:100]),
+			wantErr: "consent string is too short",
+		},
+		"core-only": {
+			consent: encodeBits(testCoreBits),
+		},
+		"with-segments": {
+			consent: encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(testPubPurposesBits),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(tc.consent)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err, "unexpected error")
+		})
+	}
+}
+
+func TestConsent(t *testing.T) {
+
+	c, err := Parse(encodeBits(testCoreBits) + "." + encodeBits(testDisclosedVendorsBits) + "." + encodeBits(testPubPurposesBits))
+	require.NoError(t, err, "unexpected error")
+
+	created := time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC)
+	require.Equal(t, 1, c.Version(), "wrong version")
+	require.Equal(t, created, c.Created(), "wrong created")
+	require.Equal(t, created, c.LastUpdated(), "wrong last updated")
+	require.Equal(t, 92, c.CMPID(), "wrong cmp id")
+	require.Equal(t, 1, c.CMPVersion(), "wrong cmp version")
+	require.Equal(t, 3, c.ConsentScreen(), "wrong consent screen")
+	require.Equal(t, "EN", c.ConsentLanguage(), "wrong consent language")
+	require.Equal(t, 34, c.VendorListVersion(), "wrong vendor list version")
+	require.Equal(t, 2, c.TcfPolicyVersion(), "wrong tcf policy version")
+	require.False(t, c.UseNonStandardStacks(), "wrong use non standard stacks")
+
+	require.Equal(t, []int{1}, collect(12, c.SpecialFeatureExpressConsent), "wrong special features express consent")
+	require.Equal(t, []int{1, 3}, collect(24, c.PurposeExpressConsent), "wrong purposes express consent")
+	require.Equal(t, []int{2}, collect(24, c.PurposeImpliedConsent), "wrong purposes implied consent")
+	require.Equal(t, []int{1, 5}, collect(20, c.VendorExpressConsent), "wrong vendors express consent")
+	require.Equal(t, []int{3, 10, 11, 12}, collect(20, c.VendorImpliedConsent), "wrong vendors implied consent")
+
+	require.True(t, c.HasDisclosedVendorsBlock(), "missing disclosed vendors block")
+	require.Equal(t, []int{1, 2, 3}, collect(20, c.IsVendorDisclosed), "wrong disclosed vendors")
+
+	require.True(t, c.HasPubPurposesBlock(), "missing publisher purposes block")
+	require.Equal(t, []int{1}, collect(24, c.PubPurposeExpressConsent), "wrong publisher purposes express consent")
+	require.Equal(t, []int{2}, collect(24, c.PubPurposeImpliedConsent), "wrong publisher purposes implied consent")
+	require.Equal(t, 2, c.NumCustomPurposes(), "wrong number of custom purposes")
+	require.Equal(t, []int{2}, collect(5, c.CustomPurposeExpressConsent), "wrong custom purposes express consent")
+	require.Equal(t, []int{1}, collect(5, c.CustomPurposeImpliedConsent), "wrong custom purposes implied consent")
+}
+
+func TestConsentWithoutSegments(t *testing.T) {
+
+	c, err := Parse(encodeBits(testCoreBits))
+	require.NoError(t, err, "unexpected error")
+
+	require.False(t, c.HasDisclosedVendorsBlock(), "unexpected disclosed vendors block")
+	require.False(t, c.IsVendorDisclosed(1), "unexpected disclosed vendor")
+	require.False(t, c.HasPubPurposesBlock(), "unexpected publisher purposes block")
+	require.False(t, c.PubPurposeExpressConsent(1), "unexpected publisher purpose express consent")
+	require.Equal(t, 0, c.NumCustomPurposes(), "unexpected custom purposes")
+	require.False(t, c.CustomPurposeImpliedConsent(1), "unexpected custom purpose implied consent")
+}
+
+func TestConsentTruncatedRange(t *testing.T) {
+
+	// the Fibonacci range of the vendors implied consent is cut before its terminator
+	core := testCoreBits[:len(testCoreBits)-len(fibonacci(7)+fibonacci(2))] + "0101"
+	c, err := Parse(encodeBits(core))
+	require.NoError(t, err, "unexpected error")
+
+	require.Equal(t, []int{1, 5}, collect(20, c.VendorExpressConsent), "wrong vendors express consent")
+	require.Empty(t, collect(20, c.VendorImpliedConsent), "truncated range must not allow any vendor")
+}
+
+// collect returns the numbers from 1 to maxNumber for which allowed returns true
+func collect(maxNumber int, allowed func(int) bool) []int {
+	var numbers []int
+	for number := 1; number <= maxNumber; number++ {
+		if allowed(number) {
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
+
+// fibonacci returns the Fibonacci encoding of n as a string of bits, terminator included
+func fibonacci(n int) string {
+	fibs := []int{1, 2}
+	for fibs[len(fibs)-1] <= n {
+		fibs = append(fibs, fibs[len(fibs)-1]+fibs[len(fibs)-2])
+	}
+	bits := make([]byte, len(fibs)-1)
+	for i := range bits {
+		bits[i] = '0'
+	}
+	for i := len(fibs) - 2; i >= 0; i-- {
+		if fibs[i] <= n {
+			bits[i] = '1'
+			n -= fibs[i]
+		}
+	}
+	return strings.TrimRight(string(bits), "0") + "1"
+}
+
+// sprintb returns the binary representation of number on bits bits
+func sprintb(number, bits int) string {
+	x := strconv.FormatInt(int64(number), 2)
+	return strings.Repeat("0", max(0, bits-len(x))) + x
+}
+
+// encodeBits encodes a string of bits, padded with zeros, as a base64 segment
+func encodeBits(bits string) string {
+	if n := len(bits) % 8; n != 0 {
+		bits += strings.Repeat("0", 8-n)
+	}
+	out := make([]byte, len(bits)/8)
+	for i := range out {
+		v, _ := strconv.ParseUint(bits[i*8:i*8+8], 2, 8)
+		out[i] = byte(v)
+	}
+	return base64.RawURLEncoding.EncodeToString(out)
+}
+
+func TestFibonacci(t *testing.T) {
+	require.Equal(t, "11", fibonacci(1))
+	require.Equal(t, "011", fibonacci(2))
+	require.Equal(t, "0011", fibonacci(3))
+	require.Equal(t, "1011", fibonacci(4))
+	require.Equal(t, "01011", fibonacci(7))
+}
+
+func TestFields(t *testing.T) {
+	core := []*iabtcf.ConsentField{
+		VersionField, CreatedField, LastUpdatedField, CMPIDField, CMPVersionField, ConsentScreenField, ConsentLanguageField,
+		VendorListVersionField, TcfPolicyVersionField, UseNonStandardStacksField, SpecialFeatureExpressConsentField,
+		PurposesExpressConsentField, PurposesImpliedConsentField,
+	}
+	for i := 1; i < len(core); i++ {
+		require.Equal(t, core[i-1].NextOffset(), core[i].Offset, "field %d doesn't follow the previous one", i)
+	}
+	require.Equal(t, 199, VendorExpressConsentOffset)
+
+	require.Equal(t, PubPurposesExpressConsentField.NextOffset(), PubPurposesImpliedConsentField.Offset)
+	require.Equal(t, PubPurposesImpliedConsentField.NextOffset(), NumCustomPurposesField.Offset)
+	require.Equal(t, 57, CustomPurposesOffset)
+}
@@ -0,0 +1,88 @@
+// Package usprivacy implements a parser of the IAB US Privacy string ( CCPA )
+//
+// The US Privacy string is made of 4 characters: the version, then the notice given, opt-out of sale
+// and LSPA covered flags, each being 'Y', 'N' or '-' when not applicable. For instance "1YNN".
+//
+// It's implemented according to specification: https://github.com/InteractiveAdvertisingBureau/USPrivacy/blob/master/CCPA/US%20Privacy%20String.md
+package usprivacy
+
+import (
+	"fmt"
+)
+
+const (
+	// Version is the only supported version of the US Privacy string
+	Version = 1
+
+	length = 4
+)
+
+// Flag is the value of a US Privacy flag
+type Flag byte
+
+const (
+	Yes           Flag = 'Y'
+	No            Flag = 'N'
+	NotApplicable Flag = '-'
+)
+
+// USPrivacy is a parsed US Privacy string
+type USPrivacy struct {
+	Version     int
+	Notice      Flag
+	OptOutSale  Flag
+	LSPACovered Flag
+}
+
+// Parse parses a US Privacy string
+//
+// note: flags are case insensitive, as some CMPs send them in lower case
+func Parse(s string) (*USPrivacy, error) {
+	if len(s) != length {
+		return nil, fmt.Errorf("us privacy string must be %d characters, got %d", length, len(s))
+	}
+	if s[0] != '0'+Version {
+		return nil, fmt.Errorf("unsupported version %q", s[0])
+	}
+
+	u := &USPrivacy{Version: Version}
+	var err error
+	if u.Notice, err = parseFlag(s[1]); err != nil {
+		return nil, fmt.Errorf("notice parse failed: %w", err)
+	}
+	if u.OptOutSale, err = parseFlag(s[2]); err != nil {
+		return nil, fmt.Errorf("opt-out sale parse failed: %w", err)
+	}
+	if u.LSPACovered, err = parseFlag(s[3]); err != nil {
+		return nil, fmt.Errorf("lspa covered parse failed: %w", err)
+	}
+	return u, nil
+}
+
+// Applies checks if CCPA applies, which is not the case when every flag is not applicable ( "1---" )
+func (u *USPrivacy) Applies() bool {
+	return u.Notice != NotApplicable || u.OptOutSale != NotApplicable || u.LSPACovered != NotApplicable
+}
+
+// OptedOut checks if user has opted out of the sale of his personal information
+func (u *USPrivacy) OptedOut() bool {
+	return u.OptOutSale == Yes
+}
+
+// String returns the US Privacy string
+func (u *USPrivacy) String() string {
+	return string([]byte{byte('0' + u.Version), byte(u.Notice), byte(u.OptOutSale), byte(u.LSPACovered)})
+}
+
+func parseFlag(c byte) (Flag, error) {
+	switch Flag(c) {
+	case Yes, 'y':
+		return Yes, nil
+	case No, 'n':
+		return No, nil
+	case NotApplicable:
+		return NotApplicable, nil
+	default:
+		return 0, fmt.Errorf("invalid flag %q", c)
+	}
+}
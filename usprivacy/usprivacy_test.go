@@ -0,0 +1,86 @@
+package usprivacy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+
+	type TestCase struct {
+		value       string
+		want        *USPrivacy
+		wantErr     string
+		wantApplies bool
+		wantOptOut  bool
+	}
+
+	testCases := map[string]*TestCase{
+		"no-opt-out": {
+			value:       "1YNN",
+			want:        &USPrivacy{Version: 1, Notice: Yes, OptOutSale: No, LSPACovered: No},
+			wantApplies: true,
+		},
+		"opt-out": {
+			value:       "1YYY",
+			want:        &USPrivacy{Version: 1, Notice: Yes, OptOutSale: Yes, LSPACovered: Yes},
+			wantApplies: true,
+			wantOptOut:  true,
+		},
+		"lower-case": {
+			value:       "1nyn",
+			want:        &USPrivacy{Version: 1, Notice: No, OptOutSale: Yes, LSPACovered: No},
+			wantApplies: true,
+			wantOptOut:  true,
+		},
+		"not-applicable": {
+			value: "1---",
+			want:  &USPrivacy{Version: 1, Notice: NotApplicable, OptOutSale: NotApplicable, LSPACovered: NotApplicable},
+		},
+		"empty": {
+			value:   "",
+			wantErr: "us privacy string must be 4 characters, got 0",
+		},
+		"too-long": {
+			value:   "1YNNY",
+			wantErr: "us privacy string must be 4 characters, got 5",
+		},
+		"wrong-version": {
+			value:   "2YNN",
+			wantErr: "unsupported version '2'",
+		},
+		"invalid-notice": {
+			value:   "1XNN",
+			wantErr: "notice parse failed: invalid flag 'X'",
+		},
+		"invalid-opt-out": {
+			value:   "1Y0N",
+			wantErr: "opt-out sale parse failed: invalid flag '0'",
+		},
+		"invalid-lspa": {
+			value:   "1YN ",
+			wantErr: "lspa covered parse failed: invalid flag ' '",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(tc.value)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, tc.want, got)
+			require.Equal(t, tc.wantApplies, got.Applies(), "wrong applies")
+			require.Equal(t, tc.wantOptOut, got.OptedOut(), "wrong opted out")
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	u, err := Parse("1ynN")
+	require.NoError(t, err, "unexpected error")
+	require.Equal(t, "1YNN", u.String())
+}
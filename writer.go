@@ -0,0 +1,172 @@
+package iabtcf
+
+import (
+	"fmt"
+	"time"
+)
+
+// Writer writes fields sequentially into a bitset, it's the counterpart of Reader
+type Writer struct {
+	bits   Bits
+	offset int
+}
+
+// NewWriter returns a new Writer
+func NewWriter() *Writer {
+	return &Writer{}
+}
+
+// Bytes returns the written bits
+//
+// note: the last byte is padded with zeros
+func (w *Writer) Bytes() []byte {
+	return w.bits
+}
+
+// writeBits writes the n lowest bits of value
+func (w *Writer) writeBits(value uint64, n uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		byteIndex := w.offset / nbBitInByte
+		if byteIndex >= len(w.bits) {
+			w.bits = append(w.bits, 0)
+		}
+		if value&(1<<i) != 0 {
+			w.bits[byteIndex] |= bitMasks[w.offset%nbBitInByte]
+		}
+		w.offset++
+	}
+}
+
+// WriteInt writes value on the next n bits
+func (w *Writer) WriteInt(value int, n uint) error {
+	if value < 0 || uint64(value) >= 1<<n {
+		return fmt.Errorf("value %d overflows %d bits", value, n)
+	}
+	w.writeBits(uint64(value), n)
+	return nil
+}
+
+// WriteBool writes value on the next bit
+func (w *Writer) WriteBool(value bool) {
+	if value {
+		w.writeBits(1, 1)
+	} else {
+		w.writeBits(0, 1)
+	}
+}
+
+// WriteTime writes t on the next 36 bits as a timestamp in deciseconds
+//
+// note: the zero time, and any time before 1970, is written as 0
+func (w *Writer) WriteTime(t time.Time) error {
+	ds := max(t.UnixMilli()/100, 0)
+	if err := w.WriteInt(int(ds), timeNbBits); err != nil {
+		return fmt.Errorf("WriteInt failed: %w", err)
+	}
+	return nil
+}
+
+// WriteString writes a string on the next length bits ( 6 bits per letter )
+//
+// note: missing letters are written as 'A'
+func (w *Writer) WriteString(value string, length int) error {
+	length = length / characterNbBits
+	if len(value) > length {
+		return fmt.Errorf("string %q is longer than %d letters", value, length)
+	}
+	for i := 0; i < length; i++ {
+		var letter byte = 'A'
+		if i < len(value) {
+			letter = value[i]
+		}
+		if letter < 'A' || letter > 'Z' {
+			return fmt.Errorf("string %q contains a character which is not an uppercase letter", value)
+		}
+		w.writeBits(uint64(letter-'A'), characterNbBits)
+	}
+	return nil
+}
+
+// WriteBitField writes the first length bits of b
+//
+// note: if b is shorter than length, it's padded with zeros
+func (w *Writer) WriteBitField(b Bits, length int) {
+	for i := 0; i < length; i++ {
+		w.WriteBool(b.ReadBoolField(i))
+	}
+}
+
+// WriteRangeEntries writes a list of range entries
+//
+// note: the number of entries is not written
+func (w *Writer) WriteRangeEntries(entries []RangeEntry) error {
+	for _, e := range entries {
+		isRange := e.EndVendorID != e.StartOrOnlyVendorId
+		w.WriteBool(isRange)
+		if err := w.WriteInt(e.StartOrOnlyVendorId, 16); err != nil {
+			return fmt.Errorf("WriteInt failed: %w", err)
+		}
+		if isRange {
+			if err := w.WriteInt(e.EndVendorID, 16); err != nil {
+				return fmt.Errorf("WriteInt failed: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// WriteVendorSection writes a vendor section: max vendor id, encoding type, then
+// either a bitfield of max vendor id bits or a list of range entries
+func (w *Writer) WriteVendorSection(s VendorSection) error {
+	if err := w.WriteInt(s.MaxVendorID, 16); err != nil {
+		return fmt.Errorf("WriteInt failed: %w", err)
+	}
+	w.WriteBool(s.IsRangeEncoding)
+	if s.IsRangeEncoding {
+		if err := w.WriteInt(len(s.RangeEntries), 12); err != nil {
+			return fmt.Errorf("WriteInt failed: %w", err)
+		}
+		if err := w.WriteRangeEntries(s.RangeEntries); err != nil {
+			return fmt.Errorf("WriteRangeEntries failed: %w", err)
+		}
+	} else {
+		w.WriteBitField(s.BitField, s.MaxVendorID)
+	}
+	return nil
+}
+
+// WritePublisherRestrictions writes the number of publisher restrictions, then each restriction
+func (w *Writer) WritePublisherRestrictions(restrictions []PublisherRestriction) error {
+	if err := w.WriteInt(len(restrictions), 12); err != nil {
+		return fmt.Errorf("WriteInt failed: %w", err)
+	}
+	for _, pr := range restrictions {
+		if err := w.WriteInt(pr.PurposeID, 6); err != nil {
+			return fmt.Errorf("WriteInt failed: %w", err)
+		}
+		if err := w.WriteInt(int(pr.RestrictionType), 2); err != nil {
+			return fmt.Errorf("WriteInt failed: %w", err)
+		}
+		if err := w.WriteInt(len(pr.RangeEntries), 12); err != nil {
+			return fmt.Errorf("WriteInt failed: %w", err)
+		}
+		if err := w.WriteRangeEntries(pr.RangeEntries); err != nil {
+			return fmt.Errorf("WriteRangeEntries failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// WritePublisherTC writes the publisher purposes transparency and consent fields
+//
+// note: the segment type is expected to be already written
+func (w *Writer) WritePublisherTC(tc *PublisherTC) error {
+	w.WriteBitField(tc.PubPurposesConsent, 24)
+	w.WriteBitField(tc.PubPurposesLITransparency, 24)
+	if err := w.WriteInt(tc.NumCustomPurposes, 6); err != nil {
+		return fmt.Errorf("WriteInt failed: %w", err)
+	}
+	w.WriteBitField(tc.CustomPurposesConsent, tc.NumCustomPurposes)
+	w.WriteBitField(tc.CustomPurposesLITransparency, tc.NumCustomPurposes)
+	return nil
+}
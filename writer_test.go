@@ -0,0 +1,61 @@
+package iabtcf
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+
+	created := time.Date(2020, 9, 23, 9, 58, 47, 900_000_000, time.UTC)
+
+	w := NewWriter()
+	require.NoError(t, w.WriteInt(2, 6))
+	require.NoError(t, w.WriteTime(created))
+	require.NoError(t, w.WriteString("EN", 12))
+	w.WriteBool(true)
+	w.WriteBitField(BitStringToBits("101"), 3)
+	require.NoError(t, w.WriteRangeEntries([]RangeEntry{{StartOrOnlyVendorId: 3, EndVendorID: 3}, {StartOrOnlyVendorId: 5, EndVendorID: 9}}))
+
+	r := NewReader(w.Bytes())
+
+	version, err := r.ReadInt(6)
+	require.NoError(t, err)
+	require.Equal(t, 2, version)
+
+	gotCreated, err := r.ReadTime()
+	require.NoError(t, err)
+	require.Equal(t, created, gotCreated)
+
+	language, err := r.ReadString(12)
+	require.NoError(t, err)
+	require.Equal(t, "EN", language)
+
+	flag, err := r.ReadBool()
+	require.NoError(t, err)
+	require.True(t, flag)
+
+	bitField, err := r.ReadBitField(3)
+	require.NoError(t, err)
+	require.Equal(t, "10100000", bitField.ToBitString())
+
+	entries, err := r.ReadRangeEntries(2)
+	require.NoError(t, err)
+	require.Equal(t, []RangeEntry{{StartOrOnlyVendorId: 3, EndVendorID: 3}, {StartOrOnlyVendorId: 5, EndVendorID: 9}}, entries)
+
+	require.EqualError(t, w.WriteInt(-1, 6), "value -1 overflows 6 bits")
+	require.EqualError(t, w.WriteString("ENG", 12), `string "ENG" is longer than 2 letters`)
+}
+
+func TestWriterErrorsWrapped(t *testing.T) {
+
+	w := NewWriter()
+	err := w.WriteVendorSection(VendorSection{MaxVendorID: 10, IsRangeEncoding: true, RangeEntries: []RangeEntry{{StartOrOnlyVendorId: 1 << 16, EndVendorID: 1 << 16}}})
+	require.EqualError(t, err, "WriteRangeEntries failed: WriteInt failed: value 65536 overflows 16 bits")
+
+	// note: the errors are wrapped, so the error of WriteInt can be unwrapped
+	require.EqualError(t, errors.Unwrap(errors.Unwrap(err)), "value 65536 overflows 16 bits")
+}